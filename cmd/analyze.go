@@ -2,26 +2,60 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pyzamo/chassis/internal/ai"
 	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/fsutil"
+	"github.com/pyzamo/chassis/internal/generalize"
 	"github.com/pyzamo/chassis/internal/github"
+	"github.com/pyzamo/chassis/internal/httpx"
+	"github.com/pyzamo/chassis/internal/parse"
+	"github.com/pyzamo/chassis/internal/registry"
+	"github.com/pyzamo/chassis/internal/validate"
 	"github.com/spf13/cobra"
 )
 
 var (
-	outputFormat string
-	maxDepth     int
+	outputFormat  string
+	maxDepth      int
+	analyzeJobs   int
+	showProgress  bool
+	followLinks   bool
+	useGitignore  bool
+	maxNodes      int
+	pruneEmpty    bool
+	projectType   string
+	noCache       bool
+	refreshCache  bool
+	cacheTTL      time.Duration
+	retries       int
+	githubAPI     string
+	noFilter      bool
+	explainFilter bool
+	showSizes     bool
+	sortOutput    bool
+	noSort        bool
+	keepDotfiles  []string
+	keepLockfiles bool
+	keepHidden    bool
+	promptFile    string
+	fallbackModel []string
+	noAI          bool
+	rawOutput     bool
 )
 
 // analyzeCmd represents the analyze command
 var analyzeCmd = &cobra.Command{
 	Use:   "analyze <source>",
 	Short: "Analyze a project with AI to extract a reusable scaffolding template",
-	Long: `Analyze an existing project directory or GitHub repository using AI to generate
-a generalized, reusable scaffolding template that can be used with the 'chassis build' command.
+	Long: `Analyze an existing project directory, archive (.tar, .tar.gz, .zip) or GitHub
+repository using AI to generate a generalized, reusable scaffolding template that
+can be used with the 'chassis build' command.
 
 This command uses Google Gemini AI to:
 - Identify architectural patterns in your project
@@ -40,7 +74,20 @@ Examples:
   
   # Analyze GitHub repository
   chassis analyze https://github.com/user/repo > structure.txt
+
+  # Analyze a downloaded tarball or zip without extracting it
+  chassis analyze ./project-main.tar.gz > structure.txt
+
+  # Analyze a published npm or PyPI package
+  chassis analyze npm:express@4.19.2 --no-ai
+  chassis analyze pypi:requests --no-ai
   
+  # Generalize the structure offline with simple heuristics, without AI
+  chassis analyze ./my-project --no-ai > template.txt
+
+  # Extract the raw structure instead
+  chassis analyze ./my-project --no-ai --raw --format yaml
+
   # Limit analysis depth
   chassis analyze ./deep-project --max-depth 3 > layout.txt`,
 	Args: cobra.ExactArgs(1),
@@ -51,8 +98,32 @@ func init() {
 	rootCmd.AddCommand(analyzeCmd)
 
 	// Local flags for analyze command
-	analyzeCmd.Flags().StringVar(&outputFormat, "format", "tree", "Output format: tree, yaml, or json")
+	analyzeCmd.Flags().StringVar(&outputFormat, "format", "tree", "Output format: tree, tree-pretty, yaml, json, or paths")
 	analyzeCmd.Flags().IntVar(&maxDepth, "max-depth", 5, "Maximum depth to analyze")
+	analyzeCmd.Flags().BoolVar(&showProgress, "progress", false, "Report scan progress to stderr (also enabled by --verbose)")
+	analyzeCmd.Flags().BoolVar(&followLinks, "follow-symlinks", false, "Descend into symlinked directories (cycles are detected)")
+	analyzeCmd.Flags().StringArrayVar(&keepDotfiles, "keep-dotfile", nil, "Keep this dotfile, and variants such as NAME.json, despite filtering (repeatable)")
+	analyzeCmd.Flags().BoolVar(&keepLockfiles, "keep-lockfiles", false, "Keep dependency lock files such as go.sum and package-lock.json")
+	analyzeCmd.Flags().BoolVar(&keepHidden, "keep-hidden-dirs", false, "Keep dot-directories such as .github and .circleci (.git, caches and editor settings are still dropped)")
+	analyzeCmd.Flags().BoolVar(&explainFilter, "explain-filter", false, "Print each filtered item and the rule that dropped it to stderr")
+	analyzeCmd.Flags().BoolVar(&useGitignore, "use-gitignore", false, "Skip entries matched by the project's .gitignore files")
+	analyzeCmd.Flags().IntVar(&maxNodes, "max-nodes", 0, "Stop scanning after this many items (0 means no limit)")
+	analyzeCmd.Flags().BoolVar(&noFilter, "no-filter", false, "Keep dependencies, build outputs and dotfiles instead of filtering them")
+	analyzeCmd.Flags().BoolVar(&pruneEmpty, "prune-empty", false, "Drop directories that contain no files after filtering")
+	analyzeCmd.Flags().BoolVar(&sortOutput, "sort", true, "Sort output entries (directories first, then alphabetical); false keeps the order they were found in")
+	analyzeCmd.Flags().BoolVar(&noSort, "no-sort", false, "Same as --sort=false")
+	analyzeCmd.Flags().BoolVar(&showSizes, "show-sizes", false, "Annotate each file with its size (tree format with --no-ai; not buildable)")
+	analyzeCmd.Flags().BoolVar(&noAI, "no-ai", false, "Skip the AI step and generalize the structure with offline heuristics: collapse runs of similar files and replace the project's name with {{name}}")
+	analyzeCmd.Flags().BoolVar(&rawOutput, "raw", false, "With --no-ai, or when no API key is set, output the filtered raw structure without generalizing it")
+	analyzeCmd.Flags().StringVar(&promptFile, "prompt-file", "", "Prompt template for the AI, with %s for the project type then the tree (default $"+ai.PromptFileEnv+" or built-in)")
+	analyzeCmd.Flags().StringArrayVar(&fallbackModel, "fallback-model", ai.DefaultFallbackModels, "Gemini model to try next when the previous one is overloaded or out of quota (repeatable; an empty value disables fallback)")
+	analyzeCmd.Flags().StringVar(&projectType, "project-type", "", "Project type hint for the AI (overrides auto-detection)")
+	analyzeCmd.Flags().BoolVar(&noCache, "no-cache", false, "Don't read or write the GitHub response cache")
+	analyzeCmd.Flags().BoolVar(&refreshCache, "refresh", false, "Re-fetch from GitHub even if a cached response exists")
+	analyzeCmd.Flags().DurationVar(&cacheTTL, "cache-ttl", github.DefaultCacheTTL, "How long cached GitHub responses are reused")
+	analyzeCmd.Flags().StringVar(&githubAPI, "github-api", "", "GitHub API base URL, for GitHub Enterprise such as https://github.example.com/api/v3 (default $"+github.APIURLEnv+" or "+github.DefaultAPIURL+")")
+	analyzeCmd.Flags().IntVar(&retries, "retries", httpx.DefaultRetryPolicy.Attempts, "Attempts for API calls that fail transiently (1 disables retries)")
+	analyzeCmd.Flags().IntVar(&analyzeJobs, "jobs", runtime.NumCPU(), "Number of directories to walk concurrently (local sources)")
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
@@ -60,8 +131,15 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 
 	// Validate output format
 	outputFormat = strings.ToLower(outputFormat)
-	if outputFormat != "tree" && outputFormat != "yaml" && outputFormat != "json" {
-		return fmt.Errorf("invalid format: %s (must be tree, yaml, or json)", outputFormat)
+	switch outputFormat {
+	case "tree", "tree-pretty", "yaml", "json", "paths":
+	default:
+		return fmt.Errorf("invalid format: %s (must be tree, tree-pretty, yaml, json, or paths)", outputFormat)
+	}
+
+	// Sizes are only known for the raw structure, not the AI's skeleton
+	if showSizes && (outputFormat != "tree" || !noAI) {
+		return fmt.Errorf("--show-sizes requires --no-ai and the tree format")
 	}
 
 	// Validate max depth
@@ -69,40 +147,194 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("max-depth must be at least 1")
 	}
 
+	// Validate node limit
+	if maxNodes < 0 {
+		return fmt.Errorf("max-nodes cannot be negative")
+	}
+
+	// Validate retry count
+	if retries < 1 {
+		return fmt.Errorf("retries must be at least 1")
+	}
+
+	// Validate job count
+	if analyzeJobs < 1 {
+		return fmt.Errorf("jobs must be at least 1")
+	}
+
+	// Validate the proxy before making any requests
+	clientOptions, err := httpOptions()
+	if err != nil {
+		return err
+	}
+
+	apiURL, err := githubAPIURL()
+	if err != nil {
+		return err
+	}
+
+	// Load a custom prompt up front so a bad template fails before any work
+	if promptFile == "" {
+		promptFile = os.Getenv(ai.PromptFileEnv)
+	}
+	var promptTemplate string
+	if promptFile != "" {
+		var err error
+		if promptTemplate, err = ai.LoadPromptTemplate(promptFile); err != nil {
+			return err
+		}
+	}
+
 	// Print progress to stderr so it doesn't mix with output
-	fmt.Fprintf(os.Stderr, "Analyzing '%s'...\n", source)
+	statusf("Analyzing '%s'...\n", source)
+
+	// Report scan progress when requested
+	var progress analyze.ProgressFunc
+	if showProgress || verbose {
+		progress = func(scanned int) {
+			fmt.Fprintf(os.Stderr, "Scanned %d items...\n", scanned)
+		}
+	}
+
+	// Say why each item was dropped when asked
+	var onFiltered analyze.FilteredFunc
+	if explainFilter {
+		onFiltered = func(path, reason string) {
+			fmt.Fprintf(os.Stderr, "filtered %s: %s\n", path, reason)
+		}
+	}
 
 	// Determine if source is GitHub URL or local path
 	var analyzer analyze.Analyzer
-	if isGitHubURL(source) {
-		fmt.Fprintf(os.Stderr, "Detected GitHub repository\n")
-		analyzer = github.NewAnalyzer(source)
+	if repoAPI, ok := githubRepoAPI(source, apiURL); ok {
+		statusf("Detected GitHub repository\n")
+		githubAnalyzer := github.NewAnalyzerWithAPI(source, repoAPI)
+		githubAnalyzer.Progress = progress
+		githubAnalyzer.MaxNodes = maxNodes
+		githubAnalyzer.Refresh = refreshCache
+		githubAnalyzer.Retry.Attempts = retries
+		githubAnalyzer.Client = httpx.NewClient(clientOptions)
+		githubAnalyzer.NoFilter = noFilter
+		githubAnalyzer.KeepDotfiles = keepDotfiles
+		githubAnalyzer.KeepLockfiles = keepLockfiles
+		githubAnalyzer.KeepHiddenDirs = keepHidden
+		githubAnalyzer.OnFiltered = onFiltered
+		if !noCache {
+			cache, err := github.NewCache(cacheTTL)
+			if err != nil {
+				statusf("Note: GitHub cache disabled: %v\n", err)
+			} else {
+				githubAnalyzer.Cache = cache
+			}
+		}
+		analyzer = githubAnalyzer
+	} else if registry.IsPackageSource(source) {
+		pkg, err := registry.ParsePackage(source)
+		if err != nil {
+			return err
+		}
+		statusf("Downloading %s...\n", pkg)
+		fetcher := registry.NewFetcher()
+		// Large packages on slow links take longer than any whole-request timeout
+		fetchOptions := clientOptions
+		fetchOptions.Timeout = httpx.NoTimeout
+		fetcher.Client = httpx.NewClient(fetchOptions)
+		fetcher.Retry.Attempts = retries
+		download, err := fetcher.Fetch(cmd.Context(), pkg)
+		if err != nil {
+			return err
+		}
+		defer download.Remove()
+
+		archiveAnalyzer := analyze.NewArchiveAnalyzer(download.Path, maxDepth)
+		archiveAnalyzer.RootName = download.Name
+		archiveAnalyzer.Progress = progress
+		archiveAnalyzer.MaxNodes = maxNodes
+		archiveAnalyzer.NoFilter = noFilter
+		archiveAnalyzer.KeepDotfiles = keepDotfiles
+		archiveAnalyzer.KeepLockfiles = keepLockfiles
+		archiveAnalyzer.KeepHiddenDirs = keepHidden
+		archiveAnalyzer.OnFiltered = onFiltered
+		analyzer = archiveAnalyzer
+	} else if analyze.IsArchive(source) && fsutil.IsFile(source) {
+		statusf("Detected archive\n")
+		archiveAnalyzer := analyze.NewArchiveAnalyzer(source, maxDepth)
+		archiveAnalyzer.Progress = progress
+		archiveAnalyzer.MaxNodes = maxNodes
+		archiveAnalyzer.NoFilter = noFilter
+		archiveAnalyzer.KeepDotfiles = keepDotfiles
+		archiveAnalyzer.KeepLockfiles = keepLockfiles
+		archiveAnalyzer.KeepHiddenDirs = keepHidden
+		archiveAnalyzer.OnFiltered = onFiltered
+		analyzer = archiveAnalyzer
 	} else {
 		// Local directory
-		analyzer = analyze.NewLocalAnalyzer(source, maxDepth)
+		localAnalyzer := analyze.NewLocalAnalyzer(source, maxDepth)
+		localAnalyzer.Workers = analyzeJobs
+		localAnalyzer.Progress = progress
+		localAnalyzer.FollowSymlinks = followLinks
+		localAnalyzer.UseGitignore = useGitignore
+		localAnalyzer.MaxNodes = maxNodes
+		localAnalyzer.NoFilter = noFilter
+		localAnalyzer.KeepDotfiles = keepDotfiles
+		localAnalyzer.KeepLockfiles = keepLockfiles
+		localAnalyzer.KeepHiddenDirs = keepHidden
+		localAnalyzer.OnFiltered = onFiltered
+		analyzer = localAnalyzer
 	}
 
 	// Perform analysis
-	result, err := analyzer.Analyze()
+	result, err := analyzer.Analyze(cmd.Context())
 	if err != nil {
+		if cmd.Context().Err() != nil {
+			return analysisCancelled(cmd, result)
+		}
 		return fmt.Errorf("analysis failed: %w", err)
 	}
 
 	// Print statistics to stderr
-	fmt.Fprintf(os.Stderr, "Found: %d directories, %d files\n", result.DirCount, result.FileCount)
+	statusf("Found: %d directories, %d files (%s)\n", result.DirCount, result.FileCount, fsutil.FormatSize(result.TotalSize))
 	if result.FilteredCount > 0 {
-		fmt.Fprintf(os.Stderr, "Filtered: %d items (build artifacts, dependencies, etc.)\n", result.FilteredCount)
+		statusf("Filtered: %d items (build artifacts, dependencies, etc.)\n", result.FilteredCount)
+	}
+	if result.Truncated {
+		fmt.Fprintf(os.Stderr, "⚠️  WARNING: results are incomplete (%s); the structure does not show the full source\n", result.TruncatedBy)
+	}
+
+	// Drop directories left empty by filtering
+	if pruneEmpty {
+		var kept []*parse.Node
+		for _, node := range result.Nodes {
+			if node.Prune() {
+				kept = append(kept, node)
+			}
+		}
+		result.Nodes = kept
 	}
 
 	// First, export the raw structure to send to AI
 	exporter := analyze.NewExporter(result.Nodes)
+	exporter.Sort = outputSortMode(sortOutput, noSort)
 	rawStructure, err := exporter.ToTreeSimple()
 	if err != nil {
 		return fmt.Errorf("failed to export structure: %w", err)
 	}
 
+	// Generalize the structure offline (or output it raw) when AI is not wanted
+	if noAI {
+		if !rawOutput {
+			return printGeneralized(result.Nodes, exporter.Sort)
+		}
+		if showSizes {
+			if rawStructure, err = exporter.ToTreeWithSizes(); err != nil {
+				return fmt.Errorf("failed to export structure: %w", err)
+			}
+		}
+		return printAnalysis(exporter, rawStructure)
+	}
+
 	// Initialize Gemini client
-	fmt.Fprintf(os.Stderr, "Analyzing patterns with AI...\n")
+	statusf("Analyzing patterns with AI...\n")
 	geminiClient, err := ai.NewGeminiClient()
 	if err != nil {
 		// If AI is not available, provide helpful message
@@ -110,6 +342,10 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "\nTo enable AI-powered skeleton extraction:\n")
 		fmt.Fprintf(os.Stderr, "1. Get a free API key from: https://aistudio.google.com/app/apikey\n")
 		fmt.Fprintf(os.Stderr, "2. Set the environment variable: export GEMINI_API_KEY='your-key-here'\n")
+		if !rawOutput {
+			fmt.Fprintf(os.Stderr, "\nFalling back to offline generalization (--raw for the raw structure)...\n\n")
+			return printGeneralized(result.Nodes, exporter.Sort)
+		}
 		fmt.Fprintf(os.Stderr, "\nFalling back to raw structure output...\n\n")
 
 		// Fall back to raw structure
@@ -117,12 +353,39 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Detect project type for better AI analysis
-	projectType := ai.DetectProjectType(rawStructure)
-	fmt.Fprintf(os.Stderr, "Detected project type: %s\n", projectType)
+	geminiClient.Retry.Attempts = retries
+	// Long answers stream for longer than any whole-request timeout
+	geminiOptions := clientOptions
+	geminiOptions.Timeout = httpx.NoTimeout
+	geminiClient.Client = httpx.NewClient(geminiOptions)
+	geminiClient.PromptTemplate = promptTemplate
+	geminiClient.FallbackModels = nil
+	for _, model := range fallbackModel {
+		if model != "" {
+			geminiClient.FallbackModels = append(geminiClient.FallbackModels, model)
+		}
+	}
+	geminiClient.OnFallback = func(from, to string, err *ai.APIError) {
+		statusf("Model %s unavailable (status %d), trying %s...\n", from, err.Status, to)
+	}
+	if verbose {
+		// Echo the response as it streams in
+		geminiClient.Stream = os.Stderr
+	}
+
+	// Detect project type for better AI analysis, unless given explicitly
+	if projectType != "" {
+		statusf("Using project type: %s (from --project-type)\n", projectType)
+	} else {
+		projectType = ai.DetectProjectType(rawStructure)
+		statusf("Detected project type: %s (override with --project-type)\n", projectType)
+	}
 
 	// Get AI-generated skeleton
-	skeleton, err := geminiClient.ExtractSkeleton(rawStructure, projectType)
+	skeleton, err := geminiClient.ExtractSkeleton(cmd.Context(), rawStructure, projectType)
+	if err != nil && cmd.Context().Err() != nil {
+		return analysisCancelled(cmd, nil)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "\n⚠️  AI analysis failed: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Falling back to raw structure output...\n\n")
@@ -130,44 +393,131 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Convert skeleton to requested format if needed
-	var output string
-	if outputFormat == "tree" {
-		output = skeleton // AI already returns in tree format
+	// Make sure the skeleton is buildable before handing it out
+	skeletonExporter := exporter
+	skeletonValid := false
+	skeletonNodes, err := parse.NewPlainTextParser(0).Parse(strings.NewReader(skeleton))
+	if err == nil {
+		err = validate.Validate(skeletonNodes)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n⚠️  AI skeleton is not a valid layout: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Falling back to raw structure output...\n\n")
+		skeleton = rawStructure
 	} else {
-		// Parse the skeleton and convert to other formats
-		// For now, we'll need to parse the AI output back into nodes
-		fmt.Fprintf(os.Stderr, "Note: YAML/JSON output currently shows raw structure. AI-generated skeleton is available in tree format only.\n")
-
-		// Fall back to raw structure for non-tree formats
-		switch outputFormat {
-		case "yaml":
-			output, err = exporter.ToYAML()
-		case "json":
-			output, err = exporter.ToJSON()
-		}
-		if err != nil {
-			return fmt.Errorf("export failed: %w", err)
-		}
+		skeletonExporter = analyze.NewExporter(skeletonNodes)
+		skeletonExporter.Sort = exporter.Sort
+		skeletonValid = true
 	}
 
-	// Print the layout to stdout
-	fmt.Print(output)
-	if !strings.HasSuffix(output, "\n") {
-		fmt.Println()
+	if err := printAnalysis(skeletonExporter, skeleton); err != nil {
+		return err
 	}
 
 	// Success message to stderr
-	fmt.Fprintf(os.Stderr, "\n✓ AI-powered analysis complete\n")
+	if skeletonValid {
+		statusf("\n✓ AI-powered analysis complete\n")
+	}
+
+	return nil
+}
+
+// printGeneralized prints the structure generalized by offline heuristics
+// in the --format output format
+func printGeneralized(nodes []*parse.Node, sort analyze.SortMode) error {
+	nodes = generalize.Generalize(nodes, generalize.DefaultOptions)
+
+	exporter := analyze.NewExporter(nodes)
+	exporter.Sort = sort
+	var tree string
+	var err error
+	if showSizes {
+		tree, err = exporter.ToTreeWithSizes()
+	} else {
+		tree, err = exporter.ToTreeSimple()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export structure: %w", err)
+	}
+	return printAnalysis(exporter, tree)
+}
+
+// analysisCancelled reports an analysis stopped by Ctrl-C, with what had
+// been found so far when result is given
+func analysisCancelled(cmd *cobra.Command, result *analyze.Result) error {
+	cmd.SilenceUsage = true
+	if result != nil {
+		fmt.Fprintf(os.Stderr, "\nInterrupted after scanning %d items: %d directories, %d files kept, %d filtered\n",
+			result.TotalScanned, result.DirCount, result.FileCount, result.FilteredCount)
+	}
+	return fmt.Errorf("analysis cancelled")
+}
+
+// outputSortMode returns the exporter order chosen by a --sort/--no-sort
+// flag pair
+func outputSortMode(sort, noSort bool) analyze.SortMode {
+	if noSort || !sort {
+		return analyze.SortAsIs
+	}
+	return analyze.SortSorted
+}
+
+// printAnalysis prints a layout to stdout in the --format output format. The
+// tree format uses the given text as-is; the others are exported from the nodes.
+func printAnalysis(exporter *analyze.Exporter, tree string) error {
+	var output string
+	var err error
+	switch outputFormat {
+	case "tree":
+		output = tree
+	case "tree-pretty":
+		output, err = exporter.ToTree()
+	case "yaml":
+		output, err = exporter.ToYAML()
+	case "json":
+		output, err = exporter.ToJSON()
+	case "paths":
+		output, err = exporter.ToPathList()
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
 
+	fmt.Print(output)
+	if !strings.HasSuffix(output, "\n") {
+		fmt.Println()
+	}
 	return nil
 }
 
-// isGitHubURL checks if the source is a GitHub URL
-func isGitHubURL(source string) bool {
-	lower := strings.ToLower(source)
-	return strings.HasPrefix(lower, "https://github.com/") ||
-		strings.HasPrefix(lower, "http://github.com/") ||
-		strings.HasPrefix(lower, "github.com/") ||
-		strings.HasPrefix(lower, "git@github.com:")
+// githubRepoAPI checks if the source is a repository URL on the GitHub
+// instance whose API is at apiURL, or on github.com, and returns the API
+// to fetch it from. github.com URLs keep working when apiURL points at an
+// Enterprise server, as GITHUB_API_URL may on CI runners.
+func githubRepoAPI(source, apiURL string) (string, bool) {
+	if github.IsRepoURL(source, github.WebHost(apiURL)) {
+		return apiURL, true
+	}
+	if github.IsRepoURL(source, github.DefaultHost) {
+		return github.DefaultAPIURL, true
+	}
+	return "", false
+}
+
+// githubAPIURL resolves the GitHub API base URL from --github-api, then
+// $GITHUB_API_URL, then the github.com default
+func githubAPIURL() (string, error) {
+	apiURL := githubAPI
+	if apiURL == "" {
+		apiURL = os.Getenv(github.APIURLEnv)
+	}
+	if apiURL == "" {
+		return github.DefaultAPIURL, nil
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid GitHub API URL %q: must be an http(s) URL such as https://github.example.com/api/v3", apiURL)
+	}
+	return strings.TrimSuffix(apiURL, "/"), nil
 }
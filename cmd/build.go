@@ -1,11 +1,20 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/fsutil"
 	"github.com/pyzamo/chassis/internal/generate"
+	"github.com/pyzamo/chassis/internal/httpx"
 	"github.com/pyzamo/chassis/internal/parse"
 	"github.com/pyzamo/chassis/internal/validate"
 	"github.com/spf13/cobra"
@@ -13,72 +22,471 @@ import (
 
 // buildCmd represents the build command
 var buildCmd = &cobra.Command{
-	Use:   "build <layout-file|-> [target-dir]",
+	Use:   "build <layout-file|url|->... [target-dir]",
 	Short: "Build directory structure from a layout definition file",
-	Long:  "Build directory structure from a layout definition file. The layout file can be in plain-text tree format, YAML, or JSON. Format is auto-detected from the file extension.",
-	Args:  cobra.RangeArgs(1, 2),
+	Long:  "Build directory structure from a layout definition file. The layout file can be in plain-text tree format, YAML, or JSON. Format is auto-detected from the file extension, or from the content when the extension is not recognized. The layout can also be fetched from an http:// or https:// URL. Several layouts can be given, in any mix of formats, followed by the target directory (required then); they are merged into one, with same-named directories combined.",
+	Args:  cobra.MinimumNArgs(1),
 	RunE:  runBuild,
 }
 
+var (
+	buildFormat  string
+	buildOutput  string
+	logFormat    string
+	buildJobs    int
+	buildDepth   int
+	dirMode      string
+	fileMode     string
+	rollback     bool
+	failFast     bool
+	emptyOnly    bool
+	interactive  bool
+	sorted       bool
+	dirsOnly     bool
+	preview      bool
+	filesOnly    bool
+	ignoreCase   bool
+	stub         bool
+	onlyGlobs    []string
+	maxFileSize  string
+	excludeGlobs []string
+
+	templateVars    []string
+	allowMissing    bool
+	expandEnv       bool
+	interactiveVars bool
+)
+
 func init() {
 	rootCmd.AddCommand(buildCmd)
+	addBuildFlags(buildCmd)
+}
 
-	// Local flags for build command
-	buildCmd.Flags().IntVar(&indentSize, "indent", 2, "Expected space width for plain-text parser (auto-detects tabs)")
+// addBuildFlags registers the flags that control a build, for build and for
+// the commands that run one, such as new
+func addBuildFlags(cmd *cobra.Command) {
+	addLayoutFlags(cmd, &buildFormat)
+	cmd.Flags().StringVar(&buildOutput, "output", "text", "Result output: text or json")
+	cmd.Flags().StringVar(&logFormat, "log-format", "text", "Progress log format: text, or json for one JSON event per line")
+	cmd.Flags().StringArrayVar(&onlyGlobs, "only", nil, "Only build paths matching this glob, plus their parent directories (** matches any depth; repeatable)")
+	cmd.Flags().StringArrayVar(&excludeGlobs, "exclude", nil, "Skip paths matching this glob, with everything below them (applied after --only; repeatable)")
+	cmd.Flags().IntVar(&buildDepth, "depth", 0, "Only build this many levels of the layout (0 means no limit)")
+	cmd.Flags().IntVar(&buildJobs, "jobs", 1, "Number of concurrent filesystem operations")
+	cmd.Flags().StringVar(&dirMode, "dir-mode", "0755", "Permissions for created directories (octal)")
+	cmd.Flags().StringVar(&fileMode, "file-mode", "0644", "Permissions for created files (octal)")
+	cmd.Flags().BoolVar(&rollback, "rollback-on-error", false, "Remove everything this run created if the build fails")
+	cmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop at the first error instead of continuing with the rest of the layout")
+	cmd.Flags().BoolVar(&ignoreCase, "case-insensitive", false, "Reject paths that differ only in case (always on for Windows and macOS)")
+	cmd.Flags().BoolVar(&sorted, "sorted", false, "Create paths in sorted order (directories first, then alphabetical)")
+	cmd.Flags().BoolVar(&preview, "preview", false, "Print the layout as a tree marking each path [NEW] or [EXISTS], without building")
+	cmd.Flags().BoolVar(&dirsOnly, "dirs-only", false, "Create directories only; files are skipped and reported as such")
+	cmd.Flags().BoolVar(&filesOnly, "files-only", false, "Create files only; parent directories are made as needed, empty directories are not")
+	cmd.Flags().StringVar(&maxFileSize, "max-file-size", "10MB", "Refuse to write more than this much content to one file (e.g. 512KB, 1GB; 0 means no limit)")
+	cmd.Flags().BoolVar(&stub, "stub", false, "Give new files without content a stub for their extension (e.g. a package clause for .go), from the config's stubs section")
+	cmd.Flags().BoolVar(&interactive, "interactive", false, "Ask before overwriting existing files (skips when stdin is not a terminal)")
+	cmd.Flags().StringArrayVar(&templateVars, "var", nil, "Replace {{name}} placeholders in names and contents (name=value, repeatable)")
+	cmd.Flags().BoolVar(&allowMissing, "allow-missing-vars", false, "Leave placeholders without a --var value as-is instead of failing")
+	cmd.Flags().BoolVar(&expandEnv, "expand-env", false, "Expand $VAR and ${VAR} in names from the environment (validated after expansion)")
+	cmd.Flags().BoolVar(&interactiveVars, "interactive-vars", false, "Prompt for placeholders without a --var value (errors when stdin is not a terminal)")
+	cmd.Flags().BoolVar(&emptyOnly, "empty-only", false, "Abort unless the target directory is empty or does not exist")
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
-	layoutFile := args[0]
+	layoutFiles := args[:1]
 	targetDir := "."
 
+	// With several arguments the last one is the target directory
 	if len(args) > 1 {
-		targetDir = args[1]
+		layoutFiles = args[:len(args)-1]
+		targetDir = args[len(args)-1]
+	}
+	fromStdin := false
+	for _, layoutFile := range layoutFiles {
+		if layoutFile == "-" {
+			if fromStdin {
+				return fmt.Errorf("stdin (-) can only be given once")
+			}
+			fromStdin = true
+		}
+	}
+
+	// Validate the output mode
+	buildOutput = strings.ToLower(buildOutput)
+	if buildOutput != "text" && buildOutput != "json" {
+		return fmt.Errorf("invalid output: %s (must be text or json)", buildOutput)
+	}
+	jsonOutput := buildOutput == "json"
+
+	// Validate the log format
+	logFormat = strings.ToLower(logFormat)
+	if logFormat != "text" && logFormat != "json" {
+		return fmt.Errorf("invalid log format: %s (must be text or json)", logFormat)
+	}
+	jsonLogs := logFormat == "json"
+
+	// Progress messages go to stderr when stdout carries the JSON result.
+	// JSON logs keep stdout for their events, so the text messages about
+	// reading the layout go to stderr then too.
+	var progress io.Writer = os.Stdout
+	if jsonOutput {
+		progress = os.Stderr
+	}
+	status := progress
+	if jsonLogs {
+		status = os.Stderr
+	}
+
+	// Validate the job count
+	if buildJobs < 1 {
+		return fmt.Errorf("jobs must be at least 1")
+	}
+
+	// Validate the depth limit
+	if buildDepth < 0 {
+		return fmt.Errorf("depth cannot be negative")
+	}
+
+	if err := checkGlobs("--only", onlyGlobs); err != nil {
+		return err
+	}
+	if err := checkGlobs("--exclude", excludeGlobs); err != nil {
+		return err
+	}
+
+	if dirsOnly && filesOnly {
+		return fmt.Errorf("--dirs-only and --files-only cannot be used together")
+	}
+
+	// Validate permissions
+	dirPerm, err := fsutil.ParseFileMode(dirMode)
+	if err != nil {
+		return fmt.Errorf("--dir-mode: %w", err)
+	}
+	filePerm, err := fsutil.ParseFileMode(fileMode)
+	if err != nil {
+		return fmt.Errorf("--file-mode: %w", err)
+	}
+
+	maxSize, err := fsutil.ParseSize(maxFileSize)
+	if err != nil {
+		return fmt.Errorf("--max-file-size: %w", err)
+	}
+
+	// Validate the format override
+	forcedFormat, err := formatFlag(buildFormat)
+	if err != nil {
+		return err
+	}
+
+	// Parse the template variables
+	vars, err := parseVars(templateVars)
+	if err != nil {
+		return err
+	}
+
+	// Steps 1-2: Read and parse the layouts, merging them into one
+	var nodes []*parse.Node
+	for _, layoutFile := range layoutFiles {
+		layout, err := readLayout(layoutFile, forcedFormat, status)
+		if err != nil {
+			return err
+		}
+		if nodes, err = parse.MergeNodes(nodes, layout); err != nil {
+			return fmt.Errorf("%s: %w", layoutFile, err)
+		}
+	}
+
+	// Fill in {{name}} placeholders, asking for unset ones when possible
+	missing := parse.MissingVars(nodes, vars)
+	if len(missing) > 0 && interactiveVars {
+		if fromStdin || !isTerminal(os.Stdin) {
+			statusf("Note: --interactive-vars needs a terminal on stdin; not prompting\n")
+		} else {
+			if err := promptVars(missing, vars, os.Stdin, os.Stderr); err != nil {
+				return err
+			}
+			missing = nil
+		}
+	}
+	if len(missing) > 0 && !allowMissing {
+		return fmt.Errorf("unresolved template variables: %s (set them with --var name=value, or pass --allow-missing-vars)", strings.Join(missing, ", "))
+	}
+	parse.SubstituteVars(nodes, vars)
+
+	// Expand environment variables in names when asked; off by default since
+	// '$' is legal in file names
+	if expandEnv {
+		parse.ExpandEnv(nodes)
+	}
+
+	// Keep only the requested part of the layout, then drop the excluded paths
+	if len(onlyGlobs) > 0 {
+		nodes = parse.Select(nodes, func(n *parse.Node) bool {
+			return matchesAny(onlyGlobs, filepath.ToSlash(n.Path))
+		})
+		if len(nodes) == 0 {
+			statusf("Warning: no paths match --only %s; nothing to build\n", strings.Join(onlyGlobs, ", "))
+			return nil
+		}
+	}
+	if len(excludeGlobs) > 0 {
+		nodes = parse.Exclude(nodes, func(n *parse.Node) bool {
+			return matchesAny(excludeGlobs, filepath.ToSlash(n.Path))
+		})
+		if len(nodes) == 0 {
+			statusf("Warning: --exclude %s removes every path; nothing to build\n", strings.Join(excludeGlobs, ", "))
+			return nil
+		}
+	}
+
+	// Cut the layout down to the requested depth
+	for _, node := range nodes {
+		node.Truncate(buildDepth)
+	}
+
+	// Step 3: Validate the tree
+	if err := validateLayout(nodes, status); err != nil {
+		return err
+	}
+
+	// Show what the build would do instead of doing it
+	if preview {
+		return printPreview(nodes, targetDir)
+	}
+
+	// Prompts need a terminal and stdin can't also carry the layout
+	if interactive && (fromStdin || !isTerminal(os.Stdin)) {
+		statusf("Note: --interactive needs a terminal on stdin; existing files will be skipped\n")
+		interactive = false
+	}
+
+	// Step 4: Generate the filesystem structure
+	var logger generate.Logger = &generate.ConsoleLogger{VerboseMode: verbose, Quiet: quiet, Stdout: progress}
+	if jsonLogs {
+		logger = generate.NewJSONLogger(progress)
+	}
+	gen := generate.NewGenerator(generate.Options{
+		TargetDir:   targetDir,
+		Verbose:     verbose,
+		Concurrency: buildJobs,
+		DirMode:     dirPerm,
+		FileMode:    filePerm,
+
+		RollbackOnError: rollback,
+		FailFast:        failFast,
+		EmptyOnly:       emptyOnly,
+		Interactive:     interactive,
+		Sorted:          sorted,
+		DirsOnly:        dirsOnly,
+		FilesOnly:       filesOnly,
+		MaxFileSize:     maxSize,
+		Stubs:           stubTemplates(),
+	}, logger)
+
+	result, err := gen.GenerateContext(cmd.Context(), nodes)
+	if cmd.Context().Err() != nil {
+		// An interrupted build is not a usage problem
+		cmd.SilenceUsage = true
+	}
+	if jsonOutput {
+		if jsonErr := printResultJSON(result); jsonErr != nil {
+			return jsonErr
+		}
+		return err
+	}
+	if err != nil {
+		// Even with errors, show what was done
+		if result != nil && !quiet && !jsonLogs {
+			result.PrintSummary()
+		}
+		return err
+	}
+
+	// JSON logs already describe every change
+	if quiet || jsonLogs {
+		return nil
+	}
+
+	// Step 5: Show summary
+	result.PrintSummary()
+
+	// Show success message
+	if result.Created > 0 || result.Skipped > 0 {
+		fmt.Printf("\n✓ Structure built in %s\n", targetDir)
+	} else {
+		fmt.Println("\nNo changes made (all paths already exist)")
+	}
+
+	return nil
+}
+
+// checkGlobs rejects malformed patterns given to flag
+func checkGlobs(flag string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid %s pattern %q: %w", flag, pattern, err)
+		}
 	}
+	return nil
+}
 
-	// Step 1: Open the input source
+// matchesAny reports whether a slash-separated path matches any of the globs
+func matchesAny(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if fsutil.MatchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// stubTemplates returns the stub templates to build with: the defaults
+// overridden by the config file's, or nil unless --stub is set
+func stubTemplates() map[string]string {
+	if !stub {
+		return nil
+	}
+	stubs := make(map[string]string)
+	for ext, template := range generate.DefaultStubs {
+		stubs[ext] = template
+	}
+	for ext, template := range configStubs {
+		stubs[ext] = template
+	}
+	return stubs
+}
+
+// addLayoutFlags registers the flags that control how a layout is read
+func addLayoutFlags(cmd *cobra.Command, format *string) {
+	cmd.Flags().IntVar(&indentSize, "indent", 2, "Expected space width for plain-text parser (auto-detects tabs)")
+	cmd.Flags().IntVar(&tabWidth, "tab-width", 0, "Expand leading tabs to N columns so lines may mix tabs and spaces (0 rejects mixing)")
+	cmd.Flags().BoolVar(&strictMode, "strict", false, "Reject trailing whitespace, tabs in names, and names ending in '.' or starting with '..' (plain-text)")
+	cmd.Flags().BoolVar(&schemaCheck, "schema-check", false, "Validate JSON layouts against the chassis layout schema before parsing")
+	cmd.Flags().StringVar(format, "format", "", "Force the input format: tree, yaml, json, or paths (overrides detection and $CHASSIS_STDIN_FORMAT)")
+}
+
+// formatFlag converts a --format value to a parse.Format; empty means detect
+func formatFlag(name string) (parse.Format, error) {
+	if name == "" {
+		return parse.FormatUnknown, nil
+	}
+	format := parse.FormatFromName(name)
+	if format == parse.FormatUnknown {
+		return format, fmt.Errorf("invalid format: %s (must be tree, yaml, json, or paths)", name)
+	}
+	return format, nil
+}
+
+// stdinFormatEnv is the environment variable that sets the format of
+// layouts read from stdin
+const stdinFormatEnv = "CHASSIS_STDIN_FORMAT"
+
+// stdinFormat returns the format of a layout read from stdin: --format if
+// given, else $CHASSIS_STDIN_FORMAT, else plain-text
+func stdinFormat(forcedFormat parse.Format) (parse.Format, error) {
+	if forcedFormat != parse.FormatUnknown {
+		return forcedFormat, nil
+	}
+	name := os.Getenv(stdinFormatEnv)
+	if name == "" {
+		return parse.FormatPlainText, nil
+	}
+	format := parse.FormatFromName(name)
+	if format == parse.FormatUnknown {
+		return format, fmt.Errorf("invalid %s: %s (must be tree, yaml, json, or paths)", stdinFormatEnv, name)
+	}
+	return format, nil
+}
+
+// loadLayout reads, parses, and validates a layout from a file, URL, or stdin ("-")
+func loadLayout(layoutFile string, forcedFormat parse.Format, progress io.Writer) ([]*parse.Node, error) {
+	nodes, err := readLayout(layoutFile, forcedFormat, progress)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLayout(nodes, progress); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// readLayout reads and parses a layout from a file, URL, or stdin ("-")
+func readLayout(layoutFile string, forcedFormat parse.Format, progress io.Writer) ([]*parse.Node, error) {
+	if tabWidth < 0 {
+		return nil, fmt.Errorf("tab-width cannot be negative")
+	}
+
+	// Open the input source
 	var reader io.Reader
+	var err error
 	var format parse.Format
+	var localFile string // Set for files on disk, which may use @include
 
 	if layoutFile == "-" {
 		// Read from stdin
 		reader = os.Stdin
-		format = parse.FormatPlainText // Default to plain-text for stdin
+		format, err = stdinFormat(forcedFormat)
+		if err != nil {
+			return nil, err
+		}
+		if verbose {
+			fmt.Fprintln(progress, "Reading from stdin...")
+		}
+	} else if isHTTPURL(layoutFile) {
+		// Fetch from URL
+		body, err := fetchLayout(layoutFile)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		reader = body
+
+		// Detect format from the URL path's extension, falling back to the content
+		u, _ := url.Parse(layoutFile)
+		format = parse.DetectFormat(u.Path)
+		if forcedFormat != parse.FormatUnknown {
+			format = forcedFormat
+		} else if format == parse.FormatUnknown {
+			format, reader = parse.DetectFormatFromContent(reader)
+		}
+
 		if verbose {
-			fmt.Println("Reading from stdin...")
+			fmt.Fprintf(progress, "Reading %s format from %s\n", format, layoutFile)
 		}
 	} else {
 		// Read from file
 		file, err := os.Open(layoutFile)
 		if err != nil {
-			return fmt.Errorf("failed to open layout file: %w", err)
+			return nil, fmt.Errorf("failed to open layout file: %w", err)
 		}
 		defer file.Close()
 		reader = file
+		localFile = layoutFile
 
-		// Detect format from file extension
+		// Detect format from file extension, falling back to the content
 		format = parse.DetectFormat(layoutFile)
-		if format == parse.FormatUnknown {
-			return fmt.Errorf("unknown file format for %s (supported: .txt, .tree, .yaml, .yml, .json)", layoutFile)
+		if forcedFormat != parse.FormatUnknown {
+			format = forcedFormat
+		} else if format == parse.FormatUnknown {
+			format, reader = parse.DetectFormatFromContent(reader)
 		}
 
 		if verbose {
-			fmt.Printf("Reading %s format from %s\n", format, layoutFile)
+			fmt.Fprintf(progress, "Reading %s format from %s\n", format, layoutFile)
 		}
 	}
 
-	// Step 2: Parse the input
-	var nodes []*parse.Node
-	var err error
-
-	if format == parse.FormatPlainText {
-		// Use the indent size flag for plain-text
-		nodes, err = parse.ParseWithIndent(reader, format, indentSize)
-	} else {
-		nodes, err = parse.Parse(reader, format)
-	}
+	// Parse the input
+	nodes, err := parse.ParseWithOptions(reader, format, parse.Options{
+		IndentWidth: indentSize,
+		TabWidth:    tabWidth,
+		Strict:      strictMode,
+		File:        localFile,
+		SchemaCheck: schemaCheck,
+	})
 
 	if err != nil {
-		return fmt.Errorf("parse error: %w", err)
+		return nil, fmt.Errorf("parse error: %w", err)
 	}
 
 	if verbose {
@@ -86,37 +494,123 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		for _, node := range nodes {
 			nodeCount += node.CountNodes()
 		}
-		fmt.Printf("Parsed %d nodes\n", nodeCount)
+		fmt.Fprintf(progress, "Parsed %d nodes\n", nodeCount)
 	}
 
-	// Step 3: Validate the tree
-	if err := validate.Validate(nodes); err != nil {
+	return nodes, nil
+}
+
+// printPreview prints the layout as a tree, marking each path [NEW] or
+// [EXISTS] according to what is already in targetDir
+func printPreview(nodes []*parse.Node, targetDir string) error {
+	newCount, existCount := 0, 0
+	exporter := analyze.NewExporter(nodes)
+	if !sorted {
+		exporter.Sort = analyze.SortAsIs
+	}
+	exporter.Annotate = func(path string, node *parse.Node) string {
+		if fsutil.PathExists(filepath.Join(targetDir, filepath.FromSlash(path))) {
+			existCount++
+			return "[EXISTS]"
+		}
+		newCount++
+		return "[NEW]"
+	}
+
+	tree, err := exporter.ToTree()
+	if err != nil {
+		return fmt.Errorf("failed to render preview: %w", err)
+	}
+	fmt.Print(tree)
+	fmt.Printf("\nNew: %d, Existing: %d\n", newCount, existCount)
+	return nil
+}
+
+// validateLayout checks a parsed layout before it is used
+func validateLayout(nodes []*parse.Node, progress io.Writer) error {
+	if err := validate.ValidateWithOptions(nodes, validate.Options{CaseInsensitive: ignoreCase}); err != nil {
 		return fmt.Errorf("validation error: %w", err)
 	}
 
 	if verbose {
-		fmt.Println("Validation passed")
+		fmt.Fprintln(progress, "Validation passed")
 	}
 
-	// Step 4: Generate the filesystem structure
-	result, err := generate.Generate(nodes, targetDir, verbose)
-	if err != nil {
-		// Even with errors, show what was done
-		if result != nil {
-			result.PrintSummary()
+	return nil
+}
+
+// parseVars converts name=value pairs from --var into a map
+func parseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --var %q (must be name=value)", pair)
 		}
-		return err
+		vars[name] = value
 	}
+	return vars, nil
+}
 
-	// Step 5: Show summary
-	result.PrintSummary()
+// promptVars asks for a value for each missing variable, adding the answers
+// to vars
+func promptVars(missing []string, vars map[string]string, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	for _, name := range missing {
+		fmt.Fprintf(out, "Value for {{%s}}: ", name)
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			fmt.Fprintln(out)
+			return fmt.Errorf("no value given for {{%s}}", name)
+		}
+		vars[name] = strings.TrimRight(line, "\r\n")
+	}
+	return nil
+}
 
-	// Show success message
-	if result.Created > 0 || result.Skipped > 0 {
-		fmt.Printf("\n✓ Structure built in %s\n", targetDir)
-	} else {
-		fmt.Println("\nNo changes made (all paths already exist)")
+// isHTTPURL checks if the layout argument is an http(s) URL
+func isHTTPURL(source string) bool {
+	lower := strings.ToLower(source)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// fetchLayout downloads a layout file and returns its body
+func fetchLayout(layoutURL string) (io.ReadCloser, error) {
+	options, err := httpOptions()
+	if err != nil {
+		return nil, err
+	}
+	client := httpx.NewClient(options)
+
+	resp, err := client.Get(layoutURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch layout: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch layout: %s returned status %d", layoutURL, resp.StatusCode)
 	}
 
+	return resp.Body, nil
+}
+
+// printResultJSON writes the generation result to stdout as JSON
+func printResultJSON(result *generate.Result) error {
+	data, err := result.ToJSON()
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
 	return nil
 }
+
+// isTerminal reports whether the file is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pyzamo/chassis/internal/config"
+	"github.com/pyzamo/chassis/internal/github"
+	"github.com/pyzamo/chassis/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect chassis configuration",
+	// Skip applying the config file so a broken one can still be located
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+// configPathCmd represents the config path command
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print where chassis looks for its config, templates and cache",
+	Long: `Print the resolved locations chassis reads from: the config file search
+order (and which file is in use), the template directory, and the GitHub
+response cache. They follow $XDG_CONFIG_HOME and $XDG_CACHE_HOME on Linux and
+the platform's usual directories on macOS and Windows.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigPath,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPathCmd)
+}
+
+func runConfigPath(cmd *cobra.Command, args []string) error {
+	inUse := config.Find()
+	if inUse == "" {
+		inUse = "(none found)"
+	}
+	fmt.Printf("Config file:   %s\n", inUse)
+	for _, path := range config.SearchPaths() {
+		fmt.Printf("  searched:    %s\n", path)
+	}
+
+	templateDir, err := templates.Dir()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Templates:     %s\n", templateDir)
+
+	cacheDir, err := github.DefaultCacheDir()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("GitHub cache:  %s\n", cacheDir)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/spf13/cobra"
+)
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert <layout-file|url|-> --to tree|yaml|json|paths",
+	Short: "Convert a layout to another format",
+	Long: `Convert a layout to another format. The input format is detected as for
+'chassis build', and the layout is validated before it is converted.
+
+Examples:
+  chassis convert layout.yaml --to tree -o layout.txt
+  chassis convert layout.txt --to json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConvert,
+}
+
+var (
+	convertFormat string
+	convertTo     string
+	convertOut    string
+)
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	addLayoutFlags(convertCmd, &convertFormat)
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "Output format: tree, yaml, json, or paths (required)")
+	convertCmd.Flags().StringVarP(&convertOut, "output", "o", "", "Write to this file instead of stdout")
+	convertCmd.MarkFlagRequired("to")
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	// Validate the target format
+	convertTo = strings.ToLower(convertTo)
+	switch convertTo {
+	case "tree", "yaml", "json", "paths":
+	default:
+		return fmt.Errorf("invalid target format: %s (must be tree, yaml, json, or paths)", convertTo)
+	}
+
+	forcedFormat, err := formatFlag(convertFormat)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := loadLayout(args[0], forcedFormat, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// Keep the author's order where the format allows it
+	exporter := analyze.NewExporter(nodes)
+	exporter.Sort = analyze.SortAsIs
+
+	var output string
+	switch convertTo {
+	case "tree":
+		output, err = exporter.ToTreeSimple()
+	case "yaml":
+		output, err = exporter.ToYAML()
+	case "json":
+		output, err = exporter.ToJSON()
+	case "paths":
+		output, err = exporter.ToPathList()
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	if convertOut == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	if err := os.WriteFile(convertOut, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", convertOut, err)
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Wrote %s layout to %s\n", convertTo, convertOut)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pyzamo/chassis/internal/fsutil"
+	"github.com/pyzamo/chassis/internal/parse"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <layout-file|url|-> [target-dir]",
+	Short: "Show what building a layout would change in a directory",
+	Long:  "Compare a layout definition against an existing directory without touching it. Each path is reported as MISSING (build would create it), EXISTS (build would skip it), CONFLICT (exists with the other type), or EXTRA (on disk but not in the layout).",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runDiff,
+}
+
+var diffFormat string
+
+// Statuses reported by diff
+const (
+	diffMissing  = "MISSING"
+	diffExists   = "EXISTS"
+	diffConflict = "CONFLICT"
+	diffExtra    = "EXTRA"
+)
+
+// diffEntry is the status of one path relative to the target directory
+type diffEntry struct {
+	status string
+	path   string
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	addLayoutFlags(diffCmd, &diffFormat)
+	diffCmd.Flags().BoolVar(&ignoreCase, "case-insensitive", false, "Reject paths that differ only in case (always on for Windows and macOS)")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	layoutFile := args[0]
+	targetDir := "."
+
+	if len(args) > 1 {
+		targetDir = args[1]
+	}
+
+	forcedFormat, err := formatFlag(diffFormat)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := loadLayout(layoutFile, forcedFormat, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	// Compare against the target, treating a missing target as empty
+	var entries []diffEntry
+	if fsutil.IsDirectory(targetDir) {
+		entries = diffNodes(nodes, targetDir, "", true)
+	} else if fsutil.PathExists(targetDir) {
+		return fmt.Errorf("target is not a directory: %s", targetDir)
+	} else {
+		entries = diffNodes(nodes, targetDir, "", false)
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		fmt.Printf("%-8s  %s\n", entry.status, entry.path)
+		counts[entry.status]++
+	}
+
+	fmt.Printf("\nMissing: %d, Existing: %d, Conflicts: %d, Extra: %d\n",
+		counts[diffMissing], counts[diffExists], counts[diffConflict], counts[diffExtra])
+
+	return nil
+}
+
+// diffNodes compares nodes against the directory dirPath, whose path relative
+// to the target is relDir. When exists is false the directory is missing, so
+// every node under it is missing too.
+func diffNodes(nodes []*parse.Node, dirPath, relDir string, exists bool) []diffEntry {
+	var entries []diffEntry
+	declared := make(map[string]bool)
+
+	for _, node := range nodes {
+		declared[node.Name] = true
+		fullPath := filepath.Join(dirPath, node.Name)
+		relPath := node.Name
+		if relDir != "" {
+			relPath = relDir + "/" + node.Name
+		}
+		display := relPath
+		if node.IsDir {
+			display += "/"
+		}
+
+		status := diffMissing
+		if exists && fsutil.PathExists(fullPath) {
+			status = diffExists
+			if node.IsDir != fsutil.IsDirectory(fullPath) {
+				status = diffConflict
+			}
+		}
+		entries = append(entries, diffEntry{status: status, path: display})
+
+		// Only descend into directories that can hold the children
+		if node.IsDir && status != diffConflict {
+			entries = append(entries, diffNodes(node.Children, fullPath, relPath, status == diffExists)...)
+		}
+	}
+
+	if exists {
+		entries = append(entries, diffExtras(dirPath, relDir, declared)...)
+	}
+
+	return entries
+}
+
+// diffExtras lists entries of dirPath that the layout doesn't declare
+func diffExtras(dirPath, relDir string, declared map[string]bool) []diffEntry {
+	dirEntries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil
+	}
+
+	var extras []string
+	for _, dirEntry := range dirEntries {
+		if declared[dirEntry.Name()] {
+			continue
+		}
+		name := dirEntry.Name()
+		if relDir != "" {
+			name = relDir + "/" + name
+		}
+		if dirEntry.IsDir() {
+			name += "/"
+		}
+		extras = append(extras, name)
+	}
+
+	sort.Slice(extras, func(i, j int) bool {
+		return strings.ToLower(extras[i]) < strings.ToLower(extras[j])
+	})
+
+	entries := make([]diffEntry, 0, len(extras))
+	for _, extra := range extras {
+		entries = append(entries, diffEntry{status: diffExtra, path: extra})
+	}
+	return entries
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// docsCmd represents the docs command; it is hidden because it is meant for
+// packaging rather than everyday use
+var docsCmd = &cobra.Command{
+	Use:    "docs",
+	Short:  "Generate documentation for chassis",
+	Hidden: true,
+}
+
+// docsManCmd represents the docs man command
+var docsManCmd = &cobra.Command{
+	Use:   "man --dir <out>",
+	Short: "Write man pages for chassis and all its commands",
+	Args:  cobra.NoArgs,
+	RunE:  runDocsMan,
+}
+
+var manDir string
+
+func init() {
+	rootCmd.AddCommand(docsCmd)
+	docsCmd.AddCommand(docsManCmd)
+
+	docsManCmd.Flags().StringVar(&manDir, "dir", "", "Directory to write the man pages to (required)")
+	docsManCmd.MarkFlagRequired("dir")
+}
+
+func runDocsMan(cmd *cobra.Command, args []string) error {
+	if err := os.MkdirAll(manDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", manDir, err)
+	}
+
+	header := &doc.GenManHeader{
+		Title:   "CHASSIS",
+		Section: "1",
+		Source:  "chassis " + rootCmd.Version,
+	}
+	rootCmd.DisableAutoGenTag = true
+	if err := doc.GenManTree(rootCmd, header, manDir); err != nil {
+		return fmt.Errorf("failed to generate man pages: %w", err)
+	}
+
+	statusf("Wrote man pages to %s\n", manDir)
+	return nil
+}
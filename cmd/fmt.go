@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/parse"
+	"github.com/spf13/cobra"
+)
+
+// fmtCmd represents the fmt command
+var fmtCmd = &cobra.Command{
+	Use:   "fmt <layout-file|->...",
+	Short: "Rewrite layouts in canonical plain-text form",
+	Long: `Rewrite layouts in canonical plain-text form: consistent indentation, a single
+trailing slash on directories, and comments kept with their entries. Input in
+any supported format is accepted; the result is printed to stdout unless
+--write is given.
+
+With --check nothing is written; the command exits with status 1 if any
+layout is not already canonical, for use in CI. --write and --check only
+apply to plain-text files; use 'chassis convert' to change a file's format.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runFmt,
+}
+
+var (
+	fmtWrite  bool
+	fmtCheck  bool
+	fmtSort   bool
+	fmtIndent int
+)
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "Write the result back to each layout file")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "Exit non-zero if any layout is not canonical, without writing")
+	fmtCmd.Flags().BoolVar(&fmtSort, "sort", false, "Sort entries (directories first, then alphabetical) instead of keeping their order")
+	fmtCmd.Flags().IntVar(&fmtIndent, "indent", 2, "Spaces per level in the output (input indentation is auto-detected)")
+}
+
+func runFmt(cmd *cobra.Command, args []string) error {
+	if fmtIndent < 1 {
+		return fmt.Errorf("indent must be at least 1")
+	}
+
+	options := analyze.FormatOptions{
+		KeepOrder:   !fmtSort,
+		IndentWidth: fmtIndent,
+	}
+
+	unformatted := 0
+	for _, layoutFile := range args {
+		ok, err := formatLayoutFile(layoutFile, options)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			unformatted++
+		}
+	}
+
+	if fmtCheck && unformatted > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d of %d layouts are not formatted", unformatted, len(args))
+	}
+
+	return nil
+}
+
+// formatLayoutFile formats one layout according to the fmt flags and
+// reports whether it was already canonical
+func formatLayoutFile(layoutFile string, options analyze.FormatOptions) (bool, error) {
+	if fmtWrite && layoutFile == "-" {
+		return false, fmt.Errorf("--write needs a layout file, not stdin")
+	}
+
+	// Read the layout
+	var data []byte
+	var err error
+	if layoutFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(layoutFile)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read layout file: %w", err)
+	}
+
+	// Detect the format from the extension, falling back to the content
+	format := parse.DetectFormat(layoutFile)
+	if format == parse.FormatUnknown {
+		format, _ = parse.DetectFormatFromContent(bytes.NewReader(data))
+	}
+	if (fmtWrite || fmtCheck) && format != parse.FormatPlainText {
+		return false, fmt.Errorf("%s is %s; --write and --check only apply to plain-text layouts", layoutFile, format)
+	}
+
+	canonical, ok, err := analyze.RoundTrip(data, format, options)
+	if err != nil {
+		return false, fmt.Errorf("%s: parse error: %w", layoutFile, err)
+	}
+
+	switch {
+	case fmtCheck:
+		if !ok {
+			fmt.Fprintln(os.Stderr, layoutFile)
+		}
+	case fmtWrite:
+		if !ok {
+			if err := os.WriteFile(layoutFile, []byte(canonical), 0644); err != nil {
+				return false, fmt.Errorf("failed to write layout file: %w", err)
+			}
+		}
+	default:
+		fmt.Print(canonical)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/generate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mimicDepth    int
+	preserveMtime bool
+)
+
+// mimicCmd represents the mimic command
+var mimicCmd = &cobra.Command{
+	Use:   "mimic <source-dir> <target-dir>",
+	Short: "Recreate the structure of an existing directory, with empty files",
+	Long: `Recreate the directories and files of an existing directory in another place,
+without their contents. This is the same as 'chassis snapshot' followed by
+'chassis build', without the layout file in between, and filters the source
+the same way.
+
+With --preserve-mtime the created files and directories get the modification
+times of their source, which is useful for test fixtures that depend on them.
+
+Examples:
+  # Make an empty copy of a project's layout
+  chassis mimic ./project ./fixture
+
+  # Keep the modification times too
+  chassis mimic ./project ./fixture --preserve-mtime`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMimic,
+}
+
+func init() {
+	rootCmd.AddCommand(mimicCmd)
+
+	mimicCmd.Flags().IntVar(&mimicDepth, "max-depth", 5, "Maximum depth to include")
+	mimicCmd.Flags().BoolVar(&followLinks, "follow-symlinks", false, "Descend into symlinked directories (cycles are detected)")
+	mimicCmd.Flags().BoolVar(&noFilter, "no-filter", false, "Keep dependencies, build outputs and dotfiles instead of filtering them")
+	mimicCmd.Flags().BoolVar(&useGitignore, "use-gitignore", false, "Skip entries matched by the project's .gitignore files")
+	mimicCmd.Flags().BoolVar(&preserveMtime, "preserve-mtime", false, "Give created files and directories the modification time of their source")
+}
+
+func runMimic(cmd *cobra.Command, args []string) error {
+	source, targetDir := args[0], args[1]
+
+	// Validate max depth
+	if mimicDepth < 1 {
+		return fmt.Errorf("max-depth must be at least 1")
+	}
+
+	localAnalyzer := analyze.NewLocalAnalyzer(source, mimicDepth)
+	localAnalyzer.FollowSymlinks = followLinks
+	localAnalyzer.UseGitignore = useGitignore
+	localAnalyzer.NoFilter = noFilter
+
+	result, err := localAnalyzer.Analyze(cmd.Context())
+	if err != nil {
+		if cmd.Context().Err() != nil {
+			return analysisCancelled(cmd, result)
+		}
+		return fmt.Errorf("analysis failed: %w", err)
+	}
+	if len(result.Nodes) == 0 {
+		statusf("Warning: nothing to recreate in %s\n", source)
+		return nil
+	}
+
+	// The analyzed root is the source directory itself; its contents go
+	// directly into the target
+	root := result.Nodes[0]
+	gen := generate.NewGenerator(generate.Options{
+		TargetDir:       targetDir,
+		Verbose:         verbose,
+		PreserveModTime: preserveMtime,
+	}, &generate.ConsoleLogger{VerboseMode: verbose, Quiet: quiet})
+
+	genResult, err := gen.GenerateContext(cmd.Context(), root.Children)
+	if cmd.Context().Err() != nil {
+		cmd.SilenceUsage = true
+	}
+	if err != nil {
+		if genResult != nil && !quiet {
+			genResult.PrintSummary()
+		}
+		return err
+	}
+
+	// The target directory itself takes the source root's time last, after
+	// its contents stopped changing it
+	if preserveMtime && !root.ModTime.IsZero() {
+		if err := os.Chtimes(targetDir, time.Time{}, root.ModTime); err != nil {
+			return fmt.Errorf("failed to set modification time of %s: %w", targetDir, err)
+		}
+	}
+
+	if quiet {
+		return nil
+	}
+	genResult.PrintSummary()
+	fmt.Printf("\n✓ Structure of %s recreated in %s\n", source, targetDir)
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pyzamo/chassis/internal/fsutil"
+	"github.com/pyzamo/chassis/internal/parse"
+	"github.com/spf13/cobra"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query <layout-file|url|-> <glob>",
+	Short: "List the paths in a layout that match a glob",
+	Long: `List the paths in a layout that match a glob. Patterns are matched against
+the full path from the layout root; "**" matches any number of directories,
+so "**/controllers/*.go" finds controllers at any depth.
+
+Exits with status 1 when nothing matches.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runQuery,
+}
+
+var queryFormat string
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	addLayoutFlags(queryCmd, &queryFormat)
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	pattern := args[1]
+
+	forcedFormat, err := formatFlag(queryFormat)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := loadLayout(args[0], forcedFormat, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	matches := 0
+	for _, node := range nodes {
+		node.Walk(func(n *parse.Node) error {
+			p := filepath.ToSlash(n.Path)
+			if !fsutil.MatchGlob(pattern, p) {
+				return nil
+			}
+			if n.IsDir {
+				p += "/"
+			}
+			fmt.Println(p)
+			matches++
+			return nil
+		})
+	}
+
+	if matches == 0 {
+		// A miss is an answer, not a usage mistake
+		cmd.SilenceUsage = true
+		return fmt.Errorf("no paths match %s", pattern)
+	}
+
+	return nil
+}
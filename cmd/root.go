@@ -1,24 +1,71 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/pyzamo/chassis/internal/config"
+	"github.com/pyzamo/chassis/internal/httpx"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	verbose    bool
-	indentSize int
+	verbose     bool
+	quiet       bool
+	indentSize  int
+	tabWidth    int
+	strictMode  bool
+	schemaCheck bool
+
+	userAgent   string
+	proxy       string
+	configStubs map[string]string // Stub templates from the config file
 )
 
 var rootCmd = &cobra.Command{
 	Use:     "chassis",
 	Short:   "A lightweight CLI tool to scaffold project directory structures",
-	Version: "0.1.0",
+	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		verboseSet, quietSet := cmd.Flags().Changed("verbose"), cmd.Flags().Changed("quiet")
+		if verboseSet && quietSet && verbose && quiet {
+			return fmt.Errorf("--verbose and --quiet cannot be used together")
+		}
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+
+		// A flag on the command line overrides the other one from the config
+		if verbose && quiet {
+			switch {
+			case quietSet:
+				verbose = false
+			case verboseSet:
+				quiet = false
+			default:
+				return fmt.Errorf("verbose and quiet cannot both be set in the config file")
+			}
+		}
+		return nil
+	},
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	// Ctrl-C cancels the command's context so in-flight work stops cleanly;
+	// a second Ctrl-C exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -26,4 +73,139 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print every path created/skipped")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print errors")
+	rootCmd.PersistentFlags().StringVar(&proxy, "proxy", "", "Proxy URL for HTTP requests, overriding HTTPS_PROXY/HTTP_PROXY (default $CHASSIS_PROXY)")
+	rootCmd.PersistentFlags().StringVar(&userAgent, "user-agent", "", "User-Agent for HTTP requests (default $CHASSIS_USER_AGENT, else chassis-cli)")
+}
+
+// statusf prints a progress or status message to stderr unless --quiet is set
+func statusf(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// httpOptions returns the HTTP client options set by the global flags
+func httpOptions() (httpx.ClientOptions, error) {
+	options := httpx.ClientOptions{UserAgent: userAgent}
+
+	// --proxy, else $CHASSIS_PROXY, else the standard proxy variables
+	proxyURL := proxy
+	if proxyURL == "" {
+		proxyURL = os.Getenv(httpx.ProxyEnv)
+	}
+	if proxyURL != "" {
+		u, err := httpx.ParseProxy(proxyURL)
+		if err != nil {
+			return options, err
+		}
+		options.Proxy = u
+	}
+
+	return options, nil
+}
+
+// applyConfig sets flags that weren't given on the command line from the
+// .chassis.yaml config file, if there is one
+func applyConfig(cmd *cobra.Command) error {
+	root := cmd.Root()
+	path := config.Find()
+	if path == "" {
+		return nil
+	}
+
+	file, err := config.Load(path)
+	if err != nil {
+		return configError(root, err)
+	}
+	configStubs = file.Stubs
+
+	// Check every key so typos surface whichever command is run
+	for _, name := range config.Keys(file.Global) {
+		if root.PersistentFlags().Lookup(name) == nil {
+			return configError(root, fmt.Errorf("%s: unknown global flag %q", path, name))
+		}
+	}
+	for command, section := range file.Commands {
+		sub := findCommand(root, command)
+		if sub == nil {
+			return configError(root, fmt.Errorf("%s: unknown command %q", path, command))
+		}
+		for _, name := range config.Keys(section) {
+			if sub.Flags().Lookup(name) == nil {
+				return configError(root, fmt.Errorf("%s: unknown flag %q for %s", path, name, command))
+			}
+		}
+	}
+
+	// Flags given explicitly win over the config
+	apply := func(section map[string][]string) error {
+		for _, name := range config.Keys(section) {
+			flag := cmd.Flags().Lookup(name)
+			if flag == nil || flag.Changed {
+				continue
+			}
+			for _, value := range section[name] {
+				if err := flag.Value.Set(value); err != nil {
+					return configError(root, fmt.Errorf("%s: invalid value %q for %s: %w", path, value, name, err))
+				}
+			}
+		}
+		return nil
+	}
+	if err := apply(file.Global); err != nil {
+		return err
+	}
+	if cmd.Parent() != root {
+		return nil
+	}
+
+	// new runs a build, so it takes the build section's defaults, with its
+	// own section winning where both set a flag
+	section := file.Commands[cmd.Name()]
+	if cmd == newCmd {
+		merged := make(map[string][]string)
+		for name, values := range file.Commands[buildCmd.Name()] {
+			merged[name] = values
+		}
+		for name, values := range section {
+			merged[name] = values
+		}
+		section = merged
+	}
+	return apply(section)
+}
+
+// findCommand returns the subcommand of root with the given name, or nil
+func findCommand(root *cobra.Command, name string) *cobra.Command {
+	for _, sub := range root.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// configError explains a bad config file, listing the keys it may contain
+func configError(root *cobra.Command, err error) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config file: %v\n\nRecognized keys (flag names, without the dashes):\n", err)
+	fmt.Fprintf(&b, "  %-10s %s\n", "(global)", strings.Join(flagNames(root.PersistentFlags()), ", "))
+	for _, sub := range root.Commands() {
+		if names := flagNames(sub.LocalNonPersistentFlags()); len(names) > 0 {
+			fmt.Fprintf(&b, "  %-10s %s\n", sub.Name()+":", strings.Join(names, ", "))
+		}
+	}
+	return fmt.Errorf("%s", strings.TrimSuffix(b.String(), "\n"))
+}
+
+// flagNames lists the names of the flags in a set, except help
+func flagNames(flags *pflag.FlagSet) []string {
+	var names []string
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name != "help" {
+			names = append(names, flag.Name)
+		}
+	})
+	return names
 }
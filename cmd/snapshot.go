@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/fsutil"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotFormat string
+	snapshotDepth  int
+	snapshotLocks  bool
+	snapshotSizes  bool
+	snapshotSort   bool
+	snapshotNoSort bool
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot <dir>",
+	Short: "Write a layout file that matches an existing directory exactly",
+	Long: `Write the structure of an existing directory as a layout file, without any AI
+generalization. Build artifacts and dependencies are filtered out the same way
+as for 'chassis analyze' unless --no-filter is given, except that dependency lock
+files are kept (--keep-lockfiles=false drops them). The layout is written to stdout.
+
+Examples:
+  # Check the current project layout into the repo
+  chassis snapshot . > layout.txt
+
+  # Recreate it elsewhere
+  chassis build layout.txt ../copy`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshot,
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+
+	snapshotCmd.Flags().StringVar(&snapshotFormat, "format", "tree", "Output format: tree, yaml, json, or paths")
+	snapshotCmd.Flags().IntVar(&snapshotDepth, "max-depth", 5, "Maximum depth to include")
+	snapshotCmd.Flags().BoolVar(&followLinks, "follow-symlinks", false, "Descend into symlinked directories (cycles are detected)")
+	snapshotCmd.Flags().BoolVar(&noFilter, "no-filter", false, "Keep dependencies, build outputs and dotfiles instead of filtering them")
+	snapshotCmd.Flags().BoolVar(&snapshotLocks, "keep-lockfiles", true, "Keep dependency lock files such as go.sum and package-lock.json")
+	snapshotCmd.Flags().BoolVar(&snapshotSort, "sort", true, "Sort entries (directories first, then alphabetical); false keeps the on-disk order")
+	snapshotCmd.Flags().BoolVar(&snapshotNoSort, "no-sort", false, "Same as --sort=false")
+	snapshotCmd.Flags().BoolVar(&snapshotSizes, "show-sizes", false, "Annotate each file with its size (tree format only; not buildable)")
+	snapshotCmd.Flags().BoolVar(&useGitignore, "use-gitignore", false, "Skip entries matched by the project's .gitignore files")
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	source := args[0]
+
+	// Validate output format
+	snapshotFormat = strings.ToLower(snapshotFormat)
+	switch snapshotFormat {
+	case "tree", "yaml", "json", "paths":
+	default:
+		return fmt.Errorf("invalid format: %s (must be tree, yaml, json, or paths)", snapshotFormat)
+	}
+
+	if snapshotSizes && snapshotFormat != "tree" {
+		return fmt.Errorf("--show-sizes requires the tree format")
+	}
+
+	// Validate max depth
+	if snapshotDepth < 1 {
+		return fmt.Errorf("max-depth must be at least 1")
+	}
+
+	localAnalyzer := analyze.NewLocalAnalyzer(source, snapshotDepth)
+	localAnalyzer.FollowSymlinks = followLinks
+	localAnalyzer.UseGitignore = useGitignore
+	localAnalyzer.NoFilter = noFilter
+	localAnalyzer.KeepLockfiles = snapshotLocks
+
+	result, err := localAnalyzer.Analyze(cmd.Context())
+	if err != nil {
+		if cmd.Context().Err() != nil {
+			return analysisCancelled(cmd, result)
+		}
+		return fmt.Errorf("snapshot failed: %w", err)
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Found: %d directories, %d files (%s)\n", result.DirCount, result.FileCount, fsutil.FormatSize(result.TotalSize))
+		if result.FilteredCount > 0 {
+			fmt.Fprintf(os.Stderr, "Filtered: %d items (build artifacts, dependencies, etc.)\n", result.FilteredCount)
+		}
+	}
+
+	exporter := analyze.NewExporter(result.Nodes)
+	exporter.Sort = outputSortMode(snapshotSort, snapshotNoSort)
+
+	var output string
+	switch snapshotFormat {
+	case "yaml":
+		output, err = exporter.ToYAML()
+	case "json":
+		output, err = exporter.ToJSON()
+	case "paths":
+		output, err = exporter.ToPathList()
+	default:
+		if snapshotSizes {
+			output, err = exporter.ToTreeWithSizes()
+		} else {
+			output, err = exporter.ToTreeForBuild()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export structure: %w", err)
+	}
+
+	fmt.Print(output)
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pyzamo/chassis/internal/parse"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats <layout-file|url|->",
+	Short: "Count the directories and files a layout would create",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runStats,
+}
+
+var (
+	statsFormat string
+	statsOutput string
+)
+
+// layoutStats summarizes the contents of a layout
+type layoutStats struct {
+	Directories int `json:"directories"`
+	Files       int `json:"files"`
+	Total       int `json:"total"`
+	MaxDepth    int `json:"max_depth"`
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	addLayoutFlags(statsCmd, &statsFormat)
+	statsCmd.Flags().StringVar(&statsOutput, "output", "text", "Result output: text or json")
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	// Validate the output mode
+	statsOutput = strings.ToLower(statsOutput)
+	if statsOutput != "text" && statsOutput != "json" {
+		return fmt.Errorf("invalid output: %s (must be text or json)", statsOutput)
+	}
+
+	forcedFormat, err := formatFlag(statsFormat)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := loadLayout(args[0], forcedFormat, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	var stats layoutStats
+	for _, node := range nodes {
+		stats.Total += node.CountNodes()
+		if depth := node.MaxDepth(); depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+		node.Walk(func(n *parse.Node) error {
+			if n.IsDir {
+				stats.Directories++
+			} else {
+				stats.Files++
+			}
+			return nil
+		})
+	}
+
+	if statsOutput == "json" {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Directories: %d\n", stats.Directories)
+	fmt.Printf("Files:       %d\n", stats.Files)
+	fmt.Printf("Total:       %d\n", stats.Total)
+	fmt.Printf("Max depth:   %d\n", stats.MaxDepth)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pyzamo/chassis/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+// newCmd represents the new command
+var newCmd = &cobra.Command{
+	Use:   "new <template-name> <target-dir>",
+	Short: "Build a directory structure from a named template",
+	Long: `Build a directory structure from a layout file in the template directory.
+Templates live in chassis/templates under the user config directory
+($XDG_CONFIG_HOME, or ~/.config, on Linux), or in $CHASSIS_TEMPLATE_DIR when it
+is set; 'chassis config path' shows where. A template's name is its file name,
+with or without the extension.
+
+Examples:
+  cp go-service.yaml ~/.config/chassis/templates/
+  chassis new go-service ./billing --var Name=billing`,
+	Args: cobra.ExactArgs(2),
+	RunE: runNew,
+}
+
+// templatesCmd represents the templates command
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage the layout templates used by 'chassis new'",
+}
+
+// templatesListCmd represents the templates list command
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available templates",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplatesList,
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesListCmd)
+
+	addBuildFlags(newCmd)
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	dir, err := templates.Dir()
+	if err != nil {
+		return err
+	}
+
+	layoutFile, err := templates.Resolve(dir, args[0])
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Using template %s\n", layoutFile)
+	}
+
+	// The rest is an ordinary build, with the same flags and config defaults
+	return runBuild(cmd, []string{layoutFile, args[1]})
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	dir, err := templates.Dir()
+	if err != nil {
+		return err
+	}
+
+	list, err := templates.List(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		fmt.Fprintf(os.Stderr, "No templates found in %s\n", dir)
+		return nil
+	}
+
+	for _, t := range list {
+		fmt.Printf("%-20s  %s\n", t.Name, t.Path)
+	}
+
+	return nil
+}
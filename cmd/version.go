@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build information, set at link time with
+// -ldflags "-X github.com/pyzamo/chassis/cmd.version=... -X ...cmd.commit=... -X ...cmd.date=..."
+var (
+	version = "0.1.0"
+	commit  = ""
+	date    = ""
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version and build information",
+	Args:  cobra.NoArgs,
+	RunE:  runVersion,
+}
+
+var versionShort bool
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+
+	versionCmd.Flags().BoolVar(&versionShort, "short", false, "Print only the version number")
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	if versionShort {
+		fmt.Println(version)
+		return nil
+	}
+
+	fmt.Printf("chassis %s\n", version)
+	fmt.Printf("  commit: %s\n", orUnknown(buildCommit()))
+	fmt.Printf("  built:  %s\n", orUnknown(date))
+	fmt.Printf("  go:     %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
+
+// buildCommit returns the commit set by ldflags, falling back to the VCS
+// revision the Go toolchain embeds (e.g. for go install)
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
+
+// orUnknown returns s, or "unknown" when it is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -2,20 +2,73 @@
 package ai
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
-	"time"
+
+	"github.com/pyzamo/chassis/internal/httpx"
 )
 
 // GeminiClient handles communication with Google Gemini API
 type GeminiClient struct {
+	Retry  httpx.RetryPolicy // Retries for transient API failures
+	Stream io.Writer         // Receives response text as it arrives, if set
+
+	// PromptTemplate replaces the built-in prompt when set; see
+	// ValidatePromptTemplate for its placeholders
+	PromptTemplate string
+
+	// Client sends the API requests; replace it to change the proxy or
+	// User-Agent (see httpx.NewClient). Responses are streamed for as long
+	// as the model writes, so it should have no overall Timeout; the
+	// request's context bounds it instead.
+	Client *http.Client
+
+	// Model is the Gemini model asked first, and FallbackModels are tried
+	// in order when it is overloaded or out of quota (status 429 or 503,
+	// after Retry gives up)
+	Model          string
+	FallbackModels []string
+
+	// OnFallback, if set, is told when a request moves on to another model
+	// and why
+	OnFallback func(from, to string, err *APIError)
+
 	apiKey string
-	client *http.Client
+}
+
+// DefaultModel is the model asked first unless configured otherwise
+const DefaultModel = "gemini-2.0-flash"
+
+// DefaultFallbackModels are tried, in order, when DefaultModel is overloaded
+var DefaultFallbackModels = []string{"gemini-2.0-flash-lite"}
+
+// APIError is an error response from the Gemini API
+type APIError struct {
+	Status  int    // HTTP status, or the error code in a streamed response (0 if none)
+	Message string // Response body or error message
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Status == 0 {
+		return fmt.Sprintf("Gemini API error: %s", e.Message)
+	}
+	return fmt.Sprintf("Gemini API error (status %d): %s", e.Status, e.Message)
+}
+
+// Overloaded reports whether the error means the model is overloaded or
+// out of quota, so another model may succeed where this one won't
+func (e *APIError) Overloaded() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status == http.StatusServiceUnavailable
 }
 
 // NewGeminiClient creates a new Gemini API client
@@ -26,20 +79,22 @@ func NewGeminiClient() (*GeminiClient, error) {
 	}
 
 	return &GeminiClient{
-		apiKey: apiKey,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		Retry:          httpx.DefaultRetryPolicy,
+		Client:         httpx.NewClient(httpx.ClientOptions{Timeout: httpx.NoTimeout}),
+		Model:          DefaultModel,
+		FallbackModels: DefaultFallbackModels,
+		apiKey:         apiKey,
 	}, nil
 }
 
-// ExtractSkeleton sends the directory structure to Gemini and gets back a generalized skeleton
-func (c *GeminiClient) ExtractSkeleton(treeStructure string, projectType string) (string, error) {
+// ExtractSkeleton sends the directory structure to Gemini and gets back a generalized skeleton.
+// Cancelling ctx aborts the request.
+func (c *GeminiClient) ExtractSkeleton(ctx context.Context, treeStructure string, projectType string) (string, error) {
 	// Prepare the prompt
 	prompt := c.buildPrompt(treeStructure, projectType)
 
 	// Call Gemini API
-	response, err := c.callGeminiAPI(prompt)
+	response, err := c.callGeminiAPI(ctx, prompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to call Gemini API: %w", err)
 	}
@@ -49,9 +104,9 @@ func (c *GeminiClient) ExtractSkeleton(treeStructure string, projectType string)
 	return skeleton, nil
 }
 
-// buildPrompt creates the prompt for Gemini
-func (c *GeminiClient) buildPrompt(treeStructure string, projectType string) string {
-	return fmt.Sprintf(`Analyze this project structure and extract a generalized, reusable scaffolding template.
+// defaultPromptTemplate is the built-in prompt. The first %s is replaced by
+// the project type and the second by the tree structure.
+const defaultPromptTemplate = `Analyze this project structure and extract a generalized, reusable scaffolding template.
 
 IMPORTANT RULES:
 1. Replace specific file names with generic descriptive names
@@ -70,7 +125,44 @@ Project type (if identifiable): %s
 Current structure:
 %s
 
-Return ONLY the generalized skeleton in tree format, nothing else. Start directly with the root folder name.`, projectType, treeStructure)
+Return ONLY the generalized skeleton in tree format, nothing else. Start directly with the root folder name.`
+
+// PromptFileEnv is the environment variable naming a prompt template file
+const PromptFileEnv = "CHASSIS_PROMPT_FILE"
+
+// LoadPromptTemplate reads a prompt template from a file and checks that it
+// can be used
+func LoadPromptTemplate(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt template: %w", err)
+	}
+
+	template := string(data)
+	if err := ValidatePromptTemplate(template); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return template, nil
+}
+
+// ValidatePromptTemplate checks that a prompt template has exactly two %s
+// placeholders: the project type, then the tree structure
+func ValidatePromptTemplate(template string) error {
+	if n := strings.Count(template, "%s"); n != 2 {
+		return fmt.Errorf("prompt template must contain exactly two %%s placeholders (project type, then tree structure), found %d", n)
+	}
+	return nil
+}
+
+// buildPrompt creates the prompt for Gemini from the client's template
+func (c *GeminiClient) buildPrompt(treeStructure string, projectType string) string {
+	// Substitute by splitting rather than with Sprintf, so a literal % in a
+	// custom template is left alone
+	parts := strings.SplitN(c.PromptTemplate, "%s", 3)
+	if len(parts) != 3 {
+		parts = strings.SplitN(defaultPromptTemplate, "%s", 3)
+	}
+	return parts[0] + projectType + parts[1] + treeStructure + parts[2]
 }
 
 // GeminiRequest represents the request structure for Gemini API
@@ -103,10 +195,33 @@ type GeminiResponse struct {
 	} `json:"error,omitempty"`
 }
 
-// callGeminiAPI makes the actual API call to Gemini
-func (c *GeminiClient) callGeminiAPI(prompt string) (string, error) {
-	// Gemini API endpoint for Gemini 2.0 Flash
-	url := "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent"
+// callGeminiAPI makes the actual API call to Gemini, streaming the
+// response. When a model is overloaded the request moves on to the next
+// fallback model.
+func (c *GeminiClient) callGeminiAPI(ctx context.Context, prompt string) (string, error) {
+	primary := c.Model
+	if primary == "" {
+		primary = DefaultModel
+	}
+	models := append([]string{primary}, c.FallbackModels...)
+
+	for i, model := range models {
+		response, err := c.callModel(ctx, model, prompt)
+		var apiErr *APIError
+		if err == nil || i == len(models)-1 || !errors.As(err, &apiErr) || !apiErr.Overloaded() {
+			return response, err
+		}
+		if c.OnFallback != nil {
+			c.OnFallback(model, models[i+1], apiErr)
+		}
+	}
+	return "", fmt.Errorf("no Gemini model to call")
+}
+
+// callModel sends the prompt to one model, streaming the response
+func (c *GeminiClient) callModel(ctx context.Context, model, prompt string) (string, error) {
+	// Gemini API streaming endpoint for the model, as server-sent events
+	endpoint := "https://generativelanguage.googleapis.com/v1beta/models/" + url.PathEscape(model) + ":streamGenerateContent?alt=sse"
 
 	// Prepare request body
 	reqBody := GeminiRequest{
@@ -124,51 +239,84 @@ func (c *GeminiClient) callGeminiAPI(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-goog-api-key", c.apiKey)
+	// Make the request, retrying transient failures
+	resp, err := c.Retry.Do(c.Client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
 
-	// Make the request
-	resp, err := c.client.Do(req)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-goog-api-key", c.apiKey)
+		return req, nil
+	})
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Gemini API error (status %d): %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(resp.Body)
+		return "", &APIError{Status: resp.StatusCode, Message: string(body)}
 	}
 
-	// Parse response
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	return c.readStream(resp.Body)
+}
+
+// readStream assembles the response text from a server-sent event stream,
+// echoing each chunk to c.Stream as it arrives
+func (c *GeminiClient) readStream(r io.Reader) (string, error) {
+	var text strings.Builder
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		// Each event carries one JSON chunk on a "data:" line
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+
+		var chunk GeminiResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk); err != nil {
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		// Check for API errors
+		if chunk.Error != nil {
+			// Once text has been streamed, leave out the code so that an
+			// overload doesn't restart the response on another model
+			status := chunk.Error.Code
+			if text.Len() > 0 {
+				status = 0
+			}
+			return "", &APIError{Status: status, Message: chunk.Error.Message}
+		}
+
+		if len(chunk.Candidates) == 0 {
+			continue
+		}
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			text.WriteString(part.Text)
+			if c.Stream != nil {
+				fmt.Fprint(c.Stream, part.Text)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Check for API errors
-	if geminiResp.Error != nil {
-		return "", fmt.Errorf("Gemini API error: %s", geminiResp.Error.Message)
+	if c.Stream != nil && text.Len() > 0 {
+		fmt.Fprintln(c.Stream)
 	}
 
-	// Extract text from response
-	if len(geminiResp.Candidates) > 0 &&
-		len(geminiResp.Candidates[0].Content.Parts) > 0 {
-		return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response text from Gemini")
 	}
 
-	return "", fmt.Errorf("no response text from Gemini")
+	return text.String(), nil
 }
 
 // extractSkeletonFromResponse cleans and extracts the skeleton from Gemini's response
@@ -178,15 +326,18 @@ func (c *GeminiClient) extractSkeletonFromResponse(response string) string {
 	var cleanedLines []string
 	inCodeBlock := false
 
+	// When the skeleton is fenced, only the fenced lines are kept
+	fenced := strings.Contains(response, "```")
+
 	for _, line := range lines {
-		// Skip markdown code blocks
+		// Skip markdown code fences
 		if strings.HasPrefix(strings.TrimSpace(line), "```") {
 			inCodeBlock = !inCodeBlock
 			continue
 		}
 
-		// Skip empty lines at the beginning and end
-		if !inCodeBlock && strings.TrimSpace(line) != "" {
+		// Skip empty lines and any prose around a fenced block
+		if (inCodeBlock || !fenced) && strings.TrimSpace(line) != "" {
 			// Remove any markdown formatting
 			line = strings.TrimPrefix(line, "- ")
 			line = strings.TrimPrefix(line, "* ")
@@ -194,6 +345,11 @@ func (c *GeminiClient) extractSkeletonFromResponse(response string) string {
 		}
 	}
 
+	// Drop any introduction such as "Here is the generalized structure:"
+	for len(cleanedLines) > 0 && !looksLikeTreeLine(cleanedLines[0]) {
+		cleanedLines = cleanedLines[1:]
+	}
+
 	// Join the lines back
 	result := strings.Join(cleanedLines, "\n")
 
@@ -203,12 +359,39 @@ func (c *GeminiClient) extractSkeletonFromResponse(response string) string {
 	return result
 }
 
+// looksLikeTreeLine reports whether a response line could belong to the
+// skeleton rather than to prose around it. Comment lines count, as do lines
+// naming a directory (trailing /) or a single name with no spaces, ignoring
+// any inline "# comment". Sentences fail both tests, and so do one-word
+// replies such as "Sure!" or "Certainly." and markdown such as "**Tree**":
+// a name ending in sentence punctuation or wrapped in emphasis is prose.
+func looksLikeTreeLine(line string) bool {
+	content := strings.TrimSpace(line)
+	if strings.HasPrefix(content, "#") {
+		return true
+	}
+	if i := strings.Index(content, " #"); i >= 0 {
+		content = strings.TrimSpace(content[:i])
+	}
+	if strings.HasSuffix(content, "/") {
+		return true
+	}
+	if content == "" || strings.ContainsAny(content, " \t") || strings.HasPrefix(content, "**") || strings.HasPrefix(content, "__") {
+		return false
+	}
+	return !strings.ContainsAny(content[len(content)-1:], ".,:;!?")
+}
+
 // DetectProjectType attempts to identify the project type from the structure
 func DetectProjectType(treeStructure string) string {
 	lower := strings.ToLower(treeStructure)
+	entries := treeEntries(lower)
 
 	// Check for various project indicators
 	switch {
+	case entries["deno.json"] || entries["deno.jsonc"]:
+		// Checked before package.json, which Deno projects often also have
+		return "Deno project"
 	case strings.Contains(lower, "package.json"):
 		if strings.Contains(lower, "react") || strings.Contains(lower, "components/") {
 			return "React/JavaScript application"
@@ -248,7 +431,46 @@ func DetectProjectType(treeStructure string) string {
 			return "Laravel project"
 		}
 		return "PHP project"
+	case entries["pubspec.yaml"]:
+		if entries["main.dart"] && (entries["android/"] || entries["ios/"]) {
+			return "Flutter application"
+		}
+		return "Dart project"
+	case entries["package.swift"]:
+		return "Swift package"
+	case entries["mix.exs"]:
+		if hasEntrySuffix(entries, "_web/") {
+			return "Elixir/Phoenix project"
+		}
+		return "Elixir project"
+	case entries["cmakelists.txt"]:
+		return "C/C++ CMake project"
+	case hasEntrySuffix(entries, ".tf"):
+		return "Terraform configuration"
 	default:
 		return "Unknown project type"
 	}
 }
+
+// treeEntries returns the set of entry names in a tree-format structure.
+// Directory names keep their trailing slash. Matching whole names avoids
+// the false positives of substring checks (e.g. ".tf" in ".tfstate.json").
+func treeEntries(treeStructure string) map[string]bool {
+	entries := make(map[string]bool)
+	for _, line := range strings.Split(treeStructure, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			entries[name] = true
+		}
+	}
+	return entries
+}
+
+// hasEntrySuffix reports whether any entry name ends with the suffix
+func hasEntrySuffix(entries map[string]bool, suffix string) bool {
+	for name := range entries {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
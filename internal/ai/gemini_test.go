@@ -0,0 +1,160 @@
+package ai
+
+import "testing"
+
+func TestDetectProjectType(t *testing.T) {
+	tests := []struct {
+		name string
+		tree string
+		want string
+	}{
+		{
+			name: "Flutter",
+			tree: "app/\n  pubspec.yaml\n  android/\n  ios/\n  lib/\n    main.dart\n",
+			want: "Flutter application",
+		},
+		{
+			name: "Dart without mobile platforms",
+			tree: "tool/\n  pubspec.yaml\n  bin/\n    main.dart\n",
+			want: "Dart project",
+		},
+		{
+			name: "Dart package with an android folder but no main.dart",
+			tree: "pkg/\n  pubspec.yaml\n  android/\n  lib/\n    pkg.dart\n",
+			want: "Dart project",
+		},
+		{
+			name: "Deno",
+			tree: "app/\n  deno.json\n  main.ts\n",
+			want: "Deno project",
+		},
+		{
+			name: "Deno with package.json",
+			tree: "app/\n  deno.jsonc\n  package.json\n  main.ts\n",
+			want: "Deno project",
+		},
+		{
+			name: "Swift package",
+			tree: "lib/\n  Package.swift\n  Sources/\n    Lib/\n      Lib.swift\n",
+			want: "Swift package",
+		},
+		{
+			name: "Elixir",
+			tree: "lib/\n  mix.exs\n  lib/\n    app.ex\n",
+			want: "Elixir project",
+		},
+		{
+			name: "Phoenix",
+			tree: "shop/\n  mix.exs\n  lib/\n    shop/\n    shop_web/\n",
+			want: "Elixir/Phoenix project",
+		},
+		{
+			name: "CMake",
+			tree: "engine/\n  CMakeLists.txt\n  src/\n    main.cpp\n",
+			want: "C/C++ CMake project",
+		},
+		{
+			name: "Terraform",
+			tree: "infra/\n  main.tf\n  variables.tf\n",
+			want: "Terraform configuration",
+		},
+		{
+			name: "Terraform state alone is not Terraform",
+			tree: "backup/\n  terraform.tfstate.json\n",
+			want: "Unknown project type",
+		},
+		{
+			name: "mix.exs mentioned in a longer name",
+			tree: "notes/\n  remix.exs.md\n",
+			want: "Unknown project type",
+		},
+		{
+			name: "pubspec.yaml inside a name",
+			tree: "docs/\n  old-pubspec.yaml.bak\n",
+			want: "Unknown project type",
+		},
+		{
+			name: "Go still detected",
+			tree: "svc/\n  go.mod\n  main.go\n",
+			want: "Go project",
+		},
+		{
+			name: "empty",
+			tree: "",
+			want: "Unknown project type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectProjectType(tt.tree); got != tt.want {
+				t.Errorf("DetectProjectType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSkeletonFromResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "bare tree",
+			response: "project/\n  src/\n    main.go\n  README.md\n",
+			want:     "project/\n  src/\n    main.go\n  README.md",
+		},
+		{
+			name: "introduction sentence",
+			response: "Here is the generalized structure:\n\n" +
+				"project/\n  src/\n    main.go\n",
+			want: "project/\n  src/\n    main.go",
+		},
+		{
+			name: "one-word reply before the tree",
+			response: "Sure!\n" +
+				"project/\n  cmd/\n    root.go\n",
+			want: "project/\n  cmd/\n    root.go",
+		},
+		{
+			name: "one-word reply and sentence",
+			response: "Certainly.\nBelow is a reusable skeleton for a Go CLI.\n\n" +
+				"app/\n  internal/\n  go.mod\n",
+			want: "app/\n  internal/\n  go.mod",
+		},
+		{
+			name: "markdown heading and bold label",
+			response: "**Skeleton**\n" +
+				"# Go service layout\n" +
+				"service/\n  handlers/\n",
+			want: "# Go service layout\nservice/\n  handlers/",
+		},
+		{
+			name: "fenced block with prose around it",
+			response: "Sure! Here's the template:\n\n```\n" +
+				"web/\n  src/\n    components/\n      Button.tsx\n" +
+				"```\n\nLet me know if you want changes!\n",
+			want: "web/\n  src/\n    components/\n      Button.tsx",
+		},
+		{
+			name:     "fenced block with a language",
+			response: "```text\n- lib/\n* README.md\n```",
+			want:     "lib/\nREADME.md",
+		},
+		{
+			name:     "root file with an inline comment",
+			response: "Okay:\nMakefile # build entry point\nsrc/\n",
+			want:     "Makefile # build entry point\nsrc/",
+		},
+	}
+
+	c := &GeminiClient{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.extractSkeletonFromResponse(tt.response); got != tt.want {
+				t.Errorf("extractSkeletonFromResponse() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
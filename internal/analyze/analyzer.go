@@ -2,6 +2,8 @@
 package analyze
 
 import (
+	"context"
+
 	"github.com/pyzamo/chassis/internal/parse"
 )
 
@@ -10,11 +12,41 @@ type Result struct {
 	Nodes         []*parse.Node // The analyzed structure
 	DirCount      int           // Number of directories found
 	FileCount     int           // Number of files found
+	TotalSize     int64         // Combined size in bytes of the files found
 	FilteredCount int           // Number of items filtered out
 	TotalScanned  int           // Total items scanned before filtering
+	Truncated     bool          // True if the walk stopped early and the result is partial
+	TruncatedBy   string        // Why the result is partial, when Truncated is set
 }
 
-// Analyzer is the interface for analyzing sources
+// Analyzer is the interface for analyzing sources. When ctx is cancelled
+// the analysis stops early, returning the partial Result along with the
+// context's error.
 type Analyzer interface {
-	Analyze() (*Result, error)
+	Analyze(ctx context.Context) (*Result, error)
+}
+
+// NodeFunc receives each node found by a streaming analysis. Returning an
+// error stops the analysis, which then returns that error.
+type NodeFunc func(node *parse.Node) error
+
+// StreamAnalyzer is an Analyzer that can emit nodes as they are found
+// instead of building the whole tree in memory. Nodes arrive without
+// Children, each after its parent, starting with the root; their Path
+// (which includes the root's name) places them in the tree. The returned
+// Result has the counts but no Nodes.
+type StreamAnalyzer interface {
+	Analyzer
+	AnalyzeStream(ctx context.Context, emit NodeFunc) (*Result, error)
 }
+
+// ProgressInterval is how many scanned items pass between progress reports
+const ProgressInterval = 500
+
+// FilteredFunc is called for each item an analysis leaves out, with its
+// path (including the root's name) and the rule that matched
+type FilteredFunc func(path, reason string)
+
+// ProgressFunc is called periodically during analysis with the number of
+// items scanned so far
+type ProgressFunc func(scanned int)
@@ -0,0 +1,326 @@
+package analyze
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// archiveExtensions are the file extensions of the archives that can be
+// analyzed, longest first so ".tar.gz" wins over ".gz"
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+// IsArchive reports whether the source names an archive that
+// ArchiveAnalyzer can read, judging by its extension
+func IsArchive(source string) bool {
+	return ArchiveExtension(source) != ""
+}
+
+// ArchiveExtension returns the extension of a file name if it is one that
+// ArchiveAnalyzer reads (such as ".tar.gz"), or ""
+func ArchiveExtension(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// ArchiveAnalyzer analyzes the structure of a .tar, .tar.gz or .zip archive
+// without extracting it
+type ArchiveAnalyzer struct {
+	Progress ProgressFunc // Optional progress callback, called every ProgressInterval items
+	MaxNodes int          // Stop after scanning this many entries (0 means no limit)
+	NoFilter bool         // Keep dependencies, build outputs and dotfiles
+
+	KeepDotfiles   []string // More dotfiles for the filter to keep (see FilterOptions)
+	KeepLockfiles  bool     // Keep dependency lock files such as go.sum
+	KeepHiddenDirs bool     // Keep dot-directories such as .github
+
+	OnFiltered FilteredFunc // Optional; told about each item left out and why
+
+	// RootName names the root of the tree instead of the archive or its
+	// single top-level directory
+	RootName string
+
+	archivePath string
+	maxDepth    int
+}
+
+// NewArchiveAnalyzer creates a new archive analyzer
+func NewArchiveAnalyzer(archivePath string, maxDepth int) *ArchiveAnalyzer {
+	return &ArchiveAnalyzer{
+		archivePath: archivePath,
+		maxDepth:    maxDepth,
+	}
+}
+
+// archiveEntry is one file or directory listed in an archive
+type archiveEntry struct {
+	path    string // Slash-separated path, cleaned
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// Analyze lists the archive and builds its tree. Entry paths map directly
+// onto the tree; directories that have no entry of their own are implied by
+// their contents. When every entry sits in one top-level directory, as in
+// GitHub's source tarballs, that directory is the root; otherwise the root
+// is named after the archive. Cancelling ctx stops with a partial result
+// and the context's error.
+func (a *ArchiveAnalyzer) Analyze(ctx context.Context) (*Result, error) {
+	entries, err := a.readEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(a.archivePath)
+	rootName := base[:len(base)-len(ArchiveExtension(base))]
+	if top := commonTopDir(entries); top != "" {
+		rootName = top
+		for i := range entries {
+			entries[i].path = strings.TrimPrefix(strings.TrimPrefix(entries[i].path, top), "/")
+		}
+	}
+	if a.RootName != "" {
+		rootName = a.RootName
+	}
+
+	root := &parse.Node{Name: rootName, IsDir: true, Path: rootName}
+	result := &Result{}
+
+	filter := NewFilterWithOptions(FilterOptions{
+		KeepDotfiles:   a.KeepDotfiles,
+		KeepLockfiles:  a.KeepLockfiles,
+		KeepHiddenDirs: a.KeepHiddenDirs,
+	})
+	if a.NoFilter {
+		filter = nil
+	}
+
+	// Directories already left out, so their contents are skipped quietly
+	skipped := make(map[string]bool)
+
+	for _, entry := range entries {
+		if entry.path == "" {
+			// The root directory itself
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			result.Truncated = true
+			result.TruncatedBy = "cancelled"
+			result.Nodes = []*parse.Node{root}
+			result.DirCount++ // Count root
+			return result, err
+		}
+
+		if a.MaxNodes > 0 && result.TotalScanned >= a.MaxNodes {
+			// Node limit reached, stop with a partial result
+			result.Truncated = true
+			result.TruncatedBy = fmt.Sprintf("stopped after %d items", a.MaxNodes)
+			break
+		}
+
+		result.TotalScanned++
+		if a.Progress != nil && result.TotalScanned%ProgressInterval == 0 {
+			a.Progress(result.TotalScanned)
+		}
+
+		a.addEntry(root, entry, filter, skipped, result)
+	}
+
+	// An empty (or fully filtered) archive has no tree
+	result.Nodes = []*parse.Node{}
+	if len(root.Children) > 0 {
+		result.Nodes = append(result.Nodes, root)
+		result.DirCount++ // Count root
+	}
+
+	return result, nil
+}
+
+// addEntry adds an entry and any directories it implies to the tree,
+// unless it or one of its parents is filtered or too deep
+func (a *ArchiveAnalyzer) addEntry(root *parse.Node, entry archiveEntry, filter *Filter, skipped map[string]bool, result *Result) {
+	parts := strings.Split(entry.path, "/")
+	current := root
+
+	for i, part := range parts {
+		isLast := i == len(parts)-1
+		isDir := entry.isDir || !isLast
+		partPath := strings.Join(parts[:i+1], "/")
+		nodePath := root.Name + "/" + partPath
+
+		if skipped[partPath] {
+			return
+		}
+
+		child := current.FindChild(part)
+		if child == nil {
+			if i >= a.maxDepth {
+				a.skip(skipped, partPath, nodePath, fmt.Sprintf("deeper than %d levels", a.maxDepth), result)
+				return
+			}
+			if filtered, reason := filter.Explain(part, isDir); filtered {
+				a.skip(skipped, partPath, nodePath, reason, result)
+				return
+			}
+
+			child = current.AddChild(part, isDir)
+			if isDir {
+				result.DirCount++
+			} else {
+				result.FileCount++
+			}
+		} else if child.IsDir != isDir {
+			// A path used as both a file and a directory; keep the first
+			return
+		}
+
+		if isLast {
+			child.ModTime = entry.modTime
+			if !isDir {
+				child.Size = entry.size
+				result.TotalSize += entry.size
+			}
+		}
+		current = child
+	}
+}
+
+// skip records an item left out of the tree, once per path
+func (a *ArchiveAnalyzer) skip(skipped map[string]bool, partPath, nodePath, reason string, result *Result) {
+	skipped[partPath] = true
+	result.FilteredCount++
+	if a.OnFiltered != nil {
+		a.OnFiltered(nodePath, reason)
+	}
+}
+
+// readEntries lists the archive's files and directories in archive order
+func (a *ArchiveAnalyzer) readEntries() ([]archiveEntry, error) {
+	switch ArchiveExtension(a.archivePath) {
+	case ".zip":
+		return readZipEntries(a.archivePath)
+	case ".tar":
+		return readTarEntries(a.archivePath, false)
+	case ".tar.gz", ".tgz":
+		return readTarEntries(a.archivePath, true)
+	default:
+		return nil, fmt.Errorf("unsupported archive: %s (must be .tar, .tar.gz, .tgz or .zip)", a.archivePath)
+	}
+}
+
+// readZipEntries lists the entries of a zip archive
+func readZipEntries(archivePath string) ([]archiveEntry, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive: %w", err)
+	}
+	defer reader.Close()
+
+	var entries []archiveEntry
+	for _, file := range reader.File {
+		info := file.FileInfo()
+		entries = append(entries, archiveEntry{
+			path:    cleanArchivePath(file.Name),
+			isDir:   info.IsDir() || strings.HasSuffix(file.Name, "/"),
+			size:    int64(file.UncompressedSize64),
+			modTime: file.Modified,
+		})
+	}
+	return entries, nil
+}
+
+// readTarEntries lists the entries of a tar archive, optionally gzipped
+func readTarEntries(archivePath string, gzipped bool) ([]archiveEntry, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open archive: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive %s: %w", archivePath, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var entries []archiveEntry
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive %s: %w", archivePath, err)
+		}
+
+		// Links become plain files; devices, FIFOs and the like are left out
+		var isDir bool
+		switch header.Typeflag {
+		case tar.TypeDir:
+			isDir = true
+		case tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+		default:
+			continue
+		}
+
+		entries = append(entries, archiveEntry{
+			path:    cleanArchivePath(header.Name),
+			isDir:   isDir,
+			size:    header.Size,
+			modTime: header.ModTime,
+		})
+	}
+	return entries, nil
+}
+
+// cleanArchivePath normalizes an entry name to a relative slash-separated
+// path. Cleaning it as an absolute path first drops any ".." that would
+// climb out of the archive root. The root itself ("./") becomes "".
+func cleanArchivePath(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimPrefix(name, "/")
+}
+
+// commonTopDir returns the single top-level directory that every entry
+// is inside, or "" when there is none
+func commonTopDir(entries []archiveEntry) string {
+	top := ""
+	for _, entry := range entries {
+		if entry.path == "" {
+			continue
+		}
+		first, _, nested := strings.Cut(entry.path, "/")
+		if !nested && !entry.isDir {
+			// A file at the top level
+			return ""
+		}
+		if top == "" {
+			top = first
+		} else if first != top {
+			return ""
+		}
+	}
+	return top
+}
@@ -4,15 +4,39 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
+	"github.com/pyzamo/chassis/internal/fsutil"
 	"github.com/pyzamo/chassis/internal/parse"
 	"gopkg.in/yaml.v3"
 )
 
+// SortMode chooses the order in which an Exporter writes sibling nodes
+type SortMode int
+
+const (
+	// SortSorted writes directories first, then alphabetically. YAML and
+	// JSON keys are sorted alphabetically.
+	SortSorted SortMode = iota
+
+	// SortAsIs writes nodes in their own order: the order they were found
+	// in by an analysis, or written in a layout file
+	SortAsIs
+)
+
 // Exporter handles exporting nodes to different formats
 type Exporter struct {
+	// Sort is the order siblings are written in (SortSorted by default)
+	Sort SortMode
+
+	// IndentWidth is the number of spaces per level in ToTreeSimple output
+	// (0 means 2)
+	IndentWidth int
+
+	// Annotate, when set, returns a label written after each node's name in
+	// ToTree output, given the node's slash-separated path from the root
+	Annotate func(path string, node *parse.Node) string
+
 	nodes []*parse.Node
 }
 
@@ -28,10 +52,10 @@ func (e *Exporter) ToTree() (string, error) {
 	var buf bytes.Buffer
 
 	// Sort root nodes for consistent output
-	sortNodes(e.nodes)
+	e.sortNodes(e.nodes)
 
 	for _, node := range e.nodes {
-		if err := e.writeTreeNode(&buf, node, "", true); err != nil {
+		if err := e.writeTreeNode(&buf, node, "", "", true, true); err != nil {
 			return "", err
 		}
 	}
@@ -40,15 +64,25 @@ func (e *Exporter) ToTree() (string, error) {
 }
 
 // writeTreeNode recursively writes a node in tree format
-func (e *Exporter) writeTreeNode(buf *bytes.Buffer, node *parse.Node, indent string, isLast bool) error {
+func (e *Exporter) writeTreeNode(buf *bytes.Buffer, node *parse.Node, parentPath, indent string, isLast, isRoot bool) error {
+	path := node.Name
+	if parentPath != "" {
+		path = parentPath + "/" + node.Name
+	}
+
 	// Write the node name
 	name := node.Name
 	if node.IsDir {
 		name += "/"
 	}
+	if e.Annotate != nil {
+		if label := e.Annotate(path, node); label != "" {
+			name += "  " + label
+		}
+	}
 
 	// Don't add tree symbols for root level
-	if indent == "" {
+	if isRoot {
 		buf.WriteString(name + "\n")
 	} else {
 		// Add tree drawing characters
@@ -60,23 +94,23 @@ func (e *Exporter) writeTreeNode(buf *bytes.Buffer, node *parse.Node, indent str
 	}
 
 	// Sort children for consistent output
-	sortNodes(node.Children)
+	e.sortNodes(node.Children)
 
-	// Process children
-	for i, child := range node.Children {
-		childIndent := indent
-		if indent == "" {
-			childIndent = ""
+	// Root children start at the left edge; deeper levels continue the
+	// vertical line of any ancestor that has later siblings
+	childIndent := indent
+	if !isRoot {
+		if isLast {
+			childIndent += "    "
 		} else {
-			if isLast {
-				childIndent += "    "
-			} else {
-				childIndent += "│   "
-			}
+			childIndent += "│   "
 		}
+	}
 
+	// Process children
+	for i, child := range node.Children {
 		isChildLast := (i == len(node.Children)-1)
-		if err := e.writeTreeNode(buf, child, childIndent, isChildLast); err != nil {
+		if err := e.writeTreeNode(buf, child, path, childIndent, isChildLast, false); err != nil {
 			return err
 		}
 	}
@@ -86,13 +120,25 @@ func (e *Exporter) writeTreeNode(buf *bytes.Buffer, node *parse.Node, indent str
 
 // ToTreeSimple exports nodes as simple indented format (compatible with build command)
 func (e *Exporter) ToTreeSimple() (string, error) {
+	return e.toTreeSimple(false)
+}
+
+// ToTreeWithSizes exports nodes like ToTreeSimple, with each file's size
+// after its name, as in "main.go (1.2 KB)". The sizes make the output
+// unsuitable for the build command.
+func (e *Exporter) ToTreeWithSizes() (string, error) {
+	return e.toTreeSimple(true)
+}
+
+// toTreeSimple exports the simple indented format, optionally with sizes
+func (e *Exporter) toTreeSimple(sizes bool) (string, error) {
 	var buf bytes.Buffer
 
 	// Sort root nodes for consistent output
-	sortNodes(e.nodes)
+	e.sortNodes(e.nodes)
 
 	for _, node := range e.nodes {
-		if err := e.writeSimpleTreeNode(&buf, node, 0); err != nil {
+		if err := e.writeSimpleTreeNode(&buf, node, 0, sizes); err != nil {
 			return "", err
 		}
 	}
@@ -101,23 +147,44 @@ func (e *Exporter) ToTreeSimple() (string, error) {
 }
 
 // writeSimpleTreeNode writes a node in simple indented format
-func (e *Exporter) writeSimpleTreeNode(buf *bytes.Buffer, node *parse.Node, depth int) error {
+func (e *Exporter) writeSimpleTreeNode(buf *bytes.Buffer, node *parse.Node, depth int, sizes bool) error {
 	// Write indentation
-	indent := strings.Repeat("  ", depth) // 2 spaces per level
+	width := e.IndentWidth
+	if width <= 0 {
+		width = 2
+	}
+	indent := strings.Repeat(" ", width*depth)
+
+	// Write the comments and blank lines that preceded the node
+	if node.Comment != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(node.Comment, "\n"), "\n") {
+			if line != "" {
+				buf.WriteString(indent + line)
+			}
+			buf.WriteString("\n")
+		}
+	}
 
 	// Write the node name
 	name := node.Name
 	if node.IsDir {
 		name += "/"
+	} else if node.Executable {
+		name += "*"
+	}
+	if node.Include != "" {
+		name = "@include " + node.Include
+	} else if sizes && !node.IsDir {
+		name += " (" + fsutil.FormatSize(node.Size) + ")"
 	}
 	buf.WriteString(indent + name + "\n")
 
 	// Sort children for consistent output
-	sortNodes(node.Children)
+	e.sortNodes(node.Children)
 
 	// Process children
 	for _, child := range node.Children {
-		if err := e.writeSimpleTreeNode(buf, child, depth+1); err != nil {
+		if err := e.writeSimpleTreeNode(buf, child, depth+1, sizes); err != nil {
 			return err
 		}
 	}
@@ -125,10 +192,50 @@ func (e *Exporter) writeSimpleTreeNode(buf *bytes.Buffer, node *parse.Node, dept
 	return nil
 }
 
+// ToPathList exports the full path of every leaf, one per line. Empty
+// directories end with a slash so the list can be parsed back.
+func (e *Exporter) ToPathList() (string, error) {
+	var buf bytes.Buffer
+
+	// Sort root nodes for consistent output
+	e.sortNodes(e.nodes)
+
+	for _, node := range e.nodes {
+		e.writePathListNode(&buf, node, "")
+	}
+
+	return buf.String(), nil
+}
+
+// writePathListNode writes the leaf paths below a node
+func (e *Exporter) writePathListNode(buf *bytes.Buffer, node *parse.Node, parentPath string) {
+	fullPath := node.Name
+	if parentPath != "" {
+		fullPath = parentPath + "/" + node.Name
+	}
+
+	if !node.IsDir {
+		buf.WriteString(fullPath + "\n")
+		return
+	}
+
+	if len(node.Children) == 0 {
+		buf.WriteString(fullPath + "/\n")
+		return
+	}
+
+	// Sort children for consistent output
+	e.sortNodes(node.Children)
+
+	for _, child := range node.Children {
+		e.writePathListNode(buf, child, fullPath)
+	}
+}
+
 // ToYAML exports nodes as YAML format
 func (e *Exporter) ToYAML() (string, error) {
 	// Convert nodes to YAML structure
-	yamlData := e.nodesToMap(e.nodes)
+	yamlData := e.nodesToData(e.nodes)
 
 	// Marshal to YAML
 	data, err := yaml.Marshal(yamlData)
@@ -142,7 +249,7 @@ func (e *Exporter) ToYAML() (string, error) {
 // ToJSON exports nodes as JSON format
 func (e *Exporter) ToJSON() (string, error) {
 	// Convert nodes to JSON structure
-	jsonData := e.nodesToMap(e.nodes)
+	jsonData := e.nodesToData(e.nodes)
 
 	// Marshal to JSON with indentation
 	data, err := json.MarshalIndent(jsonData, "", "  ")
@@ -153,12 +260,35 @@ func (e *Exporter) ToJSON() (string, error) {
 	return string(data) + "\n", nil
 }
 
+// nodesToData converts nodes to the structure marshalled for YAML/JSON: a
+// map, whose keys the encoders sort, or an orderedMap for SortAsIs
+func (e *Exporter) nodesToData(nodes []*parse.Node) interface{} {
+	if e.Sort == SortAsIs {
+		return nodesToOrderedMap(nodes)
+	}
+	return e.nodesToMap(nodes)
+}
+
+// nodeValue returns the YAML/JSON value of a file node
+func nodeValue(node *parse.Node) interface{} {
+	switch {
+	case node.Binary:
+		// File with binary content, kept base64-encoded
+		return map[string]interface{}{parse.Base64Key: node.Content}
+	case node.HasContent:
+		// File with inline content
+		return node.Content
+	default:
+		return nil
+	}
+}
+
 // nodesToMap converts nodes to a map structure for YAML/JSON
 func (e *Exporter) nodesToMap(nodes []*parse.Node) map[string]interface{} {
 	result := make(map[string]interface{})
 
 	// Sort nodes for consistent output
-	sortNodes(nodes)
+	e.sortNodes(nodes)
 
 	for _, node := range nodes {
 		if node.IsDir {
@@ -170,24 +300,85 @@ func (e *Exporter) nodesToMap(nodes []*parse.Node) map[string]interface{} {
 				result[node.Name] = map[string]interface{}{}
 			}
 		} else {
-			// File
-			result[node.Name] = nil
+			// File, with its content if any
+			result[node.Name] = nodeValue(node)
 		}
 	}
 
 	return result
 }
 
-// sortNodes sorts nodes alphabetically (directories first, then files)
-func sortNodes(nodes []*parse.Node) {
-	sort.Slice(nodes, func(i, j int) bool {
-		// If one is dir and other is file, dir comes first
-		if nodes[i].IsDir != nodes[j].IsDir {
-			return nodes[i].IsDir
+// orderedMap is a YAML/JSON mapping that keeps its keys in order
+type orderedMap []orderedEntry
+
+// orderedEntry is one key of an orderedMap
+type orderedEntry struct {
+	key   string
+	value interface{}
+}
+
+// nodesToOrderedMap converts nodes to an orderedMap, keeping their order
+func nodesToOrderedMap(nodes []*parse.Node) orderedMap {
+	result := orderedMap{}
+	for _, node := range nodes {
+		var value interface{}
+		if node.IsDir {
+			value = nodesToOrderedMap(node.Children)
+		} else {
+			value = nodeValue(node)
+		}
+		result = append(result, orderedEntry{key: node.Name, value: value})
+	}
+	return result
+}
+
+// MarshalJSON implements json.Marshaler, writing the keys in order
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(entry.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalYAML implements yaml.Marshaler, writing the keys in order
+func (m orderedMap) MarshalYAML() (interface{}, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, entry := range m {
+		var key, value yaml.Node
+		if err := key.Encode(entry.key); err != nil {
+			return nil, err
 		}
-		// Otherwise alphabetical
-		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
-	})
+		if err := value.Encode(entry.value); err != nil {
+			return nil, err
+		}
+		mapping.Content = append(mapping.Content, &key, &value)
+	}
+	return mapping, nil
+}
+
+// sortNodes sorts nodes alphabetically (directories first, then files),
+// unless the exporter keeps the original order
+func (e *Exporter) sortNodes(nodes []*parse.Node) {
+	if e.Sort == SortAsIs {
+		return
+	}
+	parse.SortNodes(nodes)
 }
 
 // ToTree actually returns the simple format for build compatibility
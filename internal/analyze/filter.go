@@ -1,6 +1,7 @@
 package analyze
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 )
@@ -12,11 +13,86 @@ type Filter struct {
 	ignoreFiles      []string
 	ignoreExtensions []string
 	ignorePrefixes   []string
+	lockFiles        []string // Dependency lock files, dropped unless kept by FilterOptions
+
+	// Dotfiles kept despite the "." prefix rule, lowercased
+	allowedDotFiles []string
+
+	// Keep hidden directories other than those in ignoreDirs
+	keepHiddenDirs bool
+}
+
+// FilterOptions adjusts the default filter
+type FilterOptions struct {
+	// KeepDotfiles lists more names starting with "." to keep, in addition
+	// to the defaults such as .gitignore and .editorconfig. Variants with
+	// a further extension are kept too: ".prettierrc" keeps
+	// ".prettierrc.json" and ".prettierrc.js".
+	KeepDotfiles []string
+
+	// KeepLockfiles keeps dependency lock files such as go.sum and
+	// package-lock.json, for a faithful copy of a project rather than a
+	// generalized one
+	KeepLockfiles bool
+
+	// KeepHiddenDirs keeps directories whose names start with ".", such as
+	// .github and .circleci. Directories on the ignore list, like .git,
+	// .cache and .vscode, are still dropped.
+	KeepHiddenDirs bool
+}
+
+// defaultDotFiles are the dotfiles kept by default, since they are common
+// config files that define project structure
+var defaultDotFiles = []string{
+	".gitignore",
+	".dockerignore",
+	".eslintrc",
+	".prettierrc",
+	".editorconfig",
+	".gitattributes",
+	".npmrc",
+	".nvmrc",
+	".ruby-version",
+	".python-version",
+	".tool-versions",
 }
 
 // NewFilter creates a new filter with default ignore patterns
 func NewFilter() *Filter {
+	return NewFilterWithOptions(FilterOptions{})
+}
+
+// NewFilterWithOptions creates a filter with the default ignore patterns,
+// adjusted by options
+func NewFilterWithOptions(options FilterOptions) *Filter {
+	var allowed []string
+	for _, names := range [][]string{defaultDotFiles, options.KeepDotfiles} {
+		for _, name := range names {
+			allowed = append(allowed, strings.ToLower(name))
+		}
+	}
+
+	// Lock files are only worth keeping in an exact copy
+	lockFiles := []string{
+		"package-lock.json",
+		"yarn.lock",
+		"pnpm-lock.yaml",
+		"Gemfile.lock",
+		"poetry.lock",
+		"Pipfile.lock",
+		"composer.lock",
+		"Cargo.lock",
+		"go.sum",
+	}
+	if options.KeepLockfiles {
+		lockFiles = nil
+	}
+
 	return &Filter{
+		allowedDotFiles: allowed,
+		lockFiles:       lockFiles,
+		keepHiddenDirs:  options.KeepHiddenDirs,
+
 		// Directories to ignore (exact match)
 		ignoreDirs: []string{
 			// Version control
@@ -95,17 +171,6 @@ func NewFilter() *Filter {
 			"Thumbs.db",
 			"desktop.ini",
 
-			// Lock files (might want to keep some)
-			"package-lock.json",
-			"yarn.lock",
-			"pnpm-lock.yaml",
-			"Gemfile.lock",
-			"poetry.lock",
-			"Pipfile.lock",
-			"composer.lock",
-			"Cargo.lock",
-			"go.sum",
-
 			// Environment files with secrets
 			".env",
 			".env.local",
@@ -147,89 +212,106 @@ func NewFilter() *Filter {
 	}
 }
 
-// ShouldFilter returns true if the given path should be filtered out
+// ShouldFilter returns true if the given path should be filtered out.
+// A nil Filter passes everything through.
 func (f *Filter) ShouldFilter(name string, isDir bool) bool {
+	filtered, _ := f.Explain(name, isDir)
+	return filtered
+}
+
+// Explain reports whether the given path should be filtered out and, if
+// so, which rule matched (for example `ignored directory "node_modules"`).
+// A nil Filter passes everything through.
+func (f *Filter) Explain(name string, isDir bool) (filtered bool, reason string) {
 	// Check for empty name
 	if name == "" {
-		return true
+		return true, "empty name"
+	}
+
+	if f == nil {
+		return false, ""
 	}
 
 	// Get lowercase name for case-insensitive matching
 	lowerName := strings.ToLower(name)
 
+	// Kept dotfiles win over every other rule, so that a variant such as
+	// .eslintrc.json isn't caught by an extension or ignore list first
+	if strings.HasPrefix(name, ".") && f.keepDotFile(lowerName) {
+		return false, ""
+	}
+
 	if isDir {
 		// Check directory ignore list
 		for _, ignore := range f.ignoreDirs {
 			if lowerName == strings.ToLower(ignore) {
-				return true
+				return true, fmt.Sprintf("ignored directory %q", ignore)
 			}
 		}
 	} else {
 		// Check file ignore list
 		for _, ignore := range f.ignoreFiles {
 			if lowerName == strings.ToLower(ignore) {
-				return true
+				return true, fmt.Sprintf("ignored file %q", ignore)
+			}
+		}
+
+		// Check lock files
+		for _, lock := range f.lockFiles {
+			if lowerName == strings.ToLower(lock) {
+				return true, fmt.Sprintf("lock file %q", lock)
 			}
 		}
 
 		// Check extensions
 		for _, ext := range f.ignoreExtensions {
 			if strings.HasSuffix(lowerName, strings.ToLower(ext)) {
-				return true
+				return true, fmt.Sprintf("ignored extension %q", ext)
 			}
 		}
 	}
 
-	// Check prefixes (for both files and directories)
-	// But be careful with "." prefix - we want to ignore most hidden files,
-	// but some like .gitignore or .dockerignore might be wanted
+	// Check prefixes (for both files and directories). Hidden files and
+	// folders are dropped, apart from the kept dotfiles checked above and
+	// any directories when they are kept.
 	if strings.HasPrefix(name, ".") {
-		// Allow some common config files that define project structure
-		allowedDotFiles := []string{
-			".gitignore",
-			".dockerignore",
-			".eslintrc",
-			".prettierrc",
-			".editorconfig",
-			".gitattributes",
-			".npmrc",
-			".nvmrc",
-			".ruby-version",
-			".python-version",
-			".tool-versions",
-		}
-
-		if !isDir {
-			for _, allowed := range allowedDotFiles {
-				if lowerName == strings.ToLower(allowed) ||
-					strings.HasPrefix(lowerName, strings.ToLower(allowed)+".") {
-					return false // Don't filter these
-				}
+		if isDir {
+			if !f.keepHiddenDirs {
+				return true, "hidden directory"
 			}
+		} else {
+			return true, "hidden file"
 		}
-
-		// Filter other dot files/folders
-		return true
 	}
 
 	// Check other prefixes
 	for _, prefix := range f.ignorePrefixes[1:] { // Skip "." as we handled it above
 		if strings.HasPrefix(name, prefix) {
-			return true
+			return true, fmt.Sprintf("temporary file prefix %q", prefix)
 		}
 	}
 
 	// Special case: filter files/dirs with certain patterns
 	// e.g., anything ending with .min.js, .bundle.js, etc.
 	if !isDir {
-		if strings.Contains(lowerName, ".min.") ||
-			strings.Contains(lowerName, ".bundle.") ||
-			strings.Contains(lowerName, ".packed.") ||
-			strings.Contains(lowerName, ".compiled.") {
-			return true
+		for _, marker := range []string{".min.", ".bundle.", ".packed.", ".compiled."} {
+			if strings.Contains(lowerName, marker) {
+				return true, fmt.Sprintf("generated file (contains %q)", marker)
+			}
 		}
 	}
 
+	return false, ""
+}
+
+// keepDotFile reports whether a lowercased name is one of the kept
+// dotfiles, or a variant of one with a further extension
+func (f *Filter) keepDotFile(lowerName string) bool {
+	for _, allowed := range f.allowedDotFiles {
+		if lowerName == allowed || strings.HasPrefix(lowerName, allowed+".") {
+			return true
+		}
+	}
 	return false
 }
 
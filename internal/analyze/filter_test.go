@@ -0,0 +1,63 @@
+package analyze
+
+import "testing"
+
+func TestFilterDotfileVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  FilterOptions
+		file     string
+		filtered bool
+	}{
+		{name: "default dotfile", file: ".prettierrc", filtered: false},
+		{name: "json variant", file: ".prettierrc.json", filtered: false},
+		{name: "js variant", file: ".prettierrc.js", filtered: false},
+		{name: "two further extensions", file: ".prettierrc.config.cjs", filtered: false},
+		{name: "variant in upper case", file: ".PRETTIERRC.JSON", filtered: false},
+		{name: "eslintrc variant", file: ".eslintrc.yaml", filtered: false},
+		{name: "longer name sharing the prefix", file: ".prettierrcx", filtered: true},
+		{name: "shorter name", file: ".prettier", filtered: true},
+		{name: "unlisted dotfile", file: ".env", filtered: true},
+		{name: "unlisted variant", file: ".env.example", filtered: true},
+		{
+			name:     "extra dotfile",
+			options:  FilterOptions{KeepDotfiles: []string{".env"}},
+			file:     ".env",
+			filtered: false,
+		},
+		{
+			name:     "variant of an extra dotfile",
+			options:  FilterOptions{KeepDotfiles: []string{".env"}},
+			file:     ".env.example",
+			filtered: false,
+		},
+		{
+			name:     "extra dotfile given in upper case",
+			options:  FilterOptions{KeepDotfiles: []string{".ENV.EXAMPLE"}},
+			file:     ".env.example",
+			filtered: false,
+		},
+		{
+			name:     "extra variant does not keep its base",
+			options:  FilterOptions{KeepDotfiles: []string{".env.example"}},
+			file:     ".env",
+			filtered: true,
+		},
+		{
+			name:     "defaults kept alongside extras",
+			options:  FilterOptions{KeepDotfiles: []string{".env"}},
+			file:     ".gitignore",
+			filtered: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewFilterWithOptions(tt.options)
+			if got := filter.ShouldFilter(tt.file, false); got != tt.filtered {
+				_, reason := filter.Explain(tt.file, false)
+				t.Errorf("ShouldFilter(%q) = %v, want %v (reason %q)", tt.file, got, tt.filtered, reason)
+			}
+		})
+	}
+}
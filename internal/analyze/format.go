@@ -0,0 +1,60 @@
+package analyze
+
+import (
+	"bytes"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// FormatOptions controls the canonical form produced by Canonical
+type FormatOptions struct {
+	KeepOrder   bool // Keep entries in their original order instead of sorting
+	IndentWidth int  // Spaces per level (0 means 2)
+
+	// TrailingComment is written after the last entry, in the form of
+	// parse.Node.Comment
+	TrailingComment string
+}
+
+// Canonical returns the canonical plain-text form of a layout: consistent
+// indentation, directories marked with a single trailing slash, and comments
+// kept with their entries, or at the end for options.TrailingComment
+func Canonical(nodes []*parse.Node, options FormatOptions) (string, error) {
+	exporter := NewExporter(nodes)
+	if options.KeepOrder {
+		exporter.Sort = SortAsIs
+	}
+	exporter.IndentWidth = options.IndentWidth
+	tree, err := exporter.ToTreeSimple()
+	if err != nil {
+		return "", err
+	}
+	return tree + options.TrailingComment, nil
+}
+
+// RoundTrip parses a layout, re-exports it with Canonical, and reports
+// whether the input was already canonical. Plain-text input has its indent
+// width auto-detected, keeps its comments after the last entry, and keeps
+// its @include lines as they are rather than inlining the included layouts.
+func RoundTrip(data []byte, format parse.Format, options FormatOptions) (string, bool, error) {
+	var nodes []*parse.Node
+	var err error
+	if format == parse.FormatPlainText {
+		plain := parse.NewPlainTextParser(0)
+		plain.KeepIncludes = true
+		nodes, err = plain.Parse(bytes.NewReader(data))
+		options.TrailingComment = plain.TrailingComment
+	} else {
+		nodes, err = parse.ParseWithOptions(bytes.NewReader(data), format, parse.Options{})
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	canonical, err := Canonical(nodes, options)
+	if err != nil {
+		return "", false, err
+	}
+
+	return canonical, canonical == string(data), nil
+}
@@ -0,0 +1,64 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "trailing comment",
+			input: "src/\n    main.go\n\n# More to come\n# later\n",
+			want:  "src/\n  main.go\n\n# More to come\n# later\n",
+		},
+		{
+			name:  "comment-only file",
+			input: "# Nothing here yet\n\n# TODO: add src/\n",
+			want:  "# Nothing here yet\n\n# TODO: add src/\n",
+		},
+		{
+			name:  "trailing blank lines dropped",
+			input: "README.md\n# end\n\n\n",
+			want:  "README.md\n# end\n",
+		},
+		{
+			name:  "include kept verbatim",
+			input: "# Shared files\n@include common.txt\nsrc/\n    # Generated\n    @include ../gen/layout.txt\n    main.go\n",
+			want:  "# Shared files\n@include common.txt\nsrc/\n  # Generated\n  @include ../gen/layout.txt\n  main.go\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := RoundTrip([]byte(tt.input), parse.FormatPlainText, FormatOptions{KeepOrder: true})
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RoundTrip = %q, want %q", got, tt.want)
+			}
+			if ok != (tt.input == tt.want) {
+				t.Errorf("canonical = %v for input %q", ok, tt.input)
+			}
+
+			// The canonical form is stable
+			again, ok, err := RoundTrip([]byte(got), parse.FormatPlainText, FormatOptions{KeepOrder: true})
+			if err != nil || !ok {
+				t.Errorf("RoundTrip of the canonical form = %q, %v, %v; want it unchanged", again, ok, err)
+			}
+		})
+	}
+}
+
+func TestRoundTripIncludeCannotHaveChildren(t *testing.T) {
+	input := "@include common.txt\n  main.go\n"
+	if _, _, err := RoundTrip([]byte(input), parse.FormatPlainText, FormatOptions{}); err == nil {
+		t.Error("RoundTrip accepted a line indented under @include")
+	}
+}
@@ -0,0 +1,131 @@
+package analyze
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pyzamo/chassis/internal/fsutil"
+)
+
+// gitignorePattern is a single pattern from a .gitignore file
+type gitignorePattern struct {
+	glob     string // Pattern without the '!', leading '/' or trailing '/'
+	base     string // Directory of the .gitignore, relative to the analysis root
+	negate   bool   // Pattern started with '!' and re-includes matches
+	dirOnly  bool   // Pattern ended with '/' and only matches directories
+	anchored bool   // Pattern contains a '/' and matches relative to base
+}
+
+// GitIgnore matches paths against the patterns of one or more .gitignore files
+type GitIgnore struct {
+	patterns []gitignorePattern
+}
+
+// LoadGitIgnore reads the .gitignore in dirPath, if any, and returns a matcher
+// that applies its patterns after those of the receiver. relDir is dirPath
+// relative to the analysis root in slash form ("" for the root itself). The
+// receiver is never modified, so matchers can be shared between walkers.
+func (g *GitIgnore) LoadGitIgnore(dirPath, relDir string) *GitIgnore {
+	file, err := os.Open(filepath.Join(dirPath, ".gitignore"))
+	if err != nil {
+		return g
+	}
+	defer file.Close()
+
+	var patterns []gitignorePattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if pattern, ok := parseGitignoreLine(scanner.Text(), relDir); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	if len(patterns) == 0 {
+		return g
+	}
+
+	merged := &GitIgnore{}
+	if g != nil {
+		merged.patterns = append(merged.patterns, g.patterns...)
+	}
+	merged.patterns = append(merged.patterns, patterns...)
+	return merged
+}
+
+// parseGitignoreLine parses one line of a .gitignore file
+func parseGitignoreLine(line, base string) (gitignorePattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+
+	// Skip blank lines and comments
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignorePattern{}, false
+	}
+
+	pattern := gitignorePattern{base: base}
+
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	}
+	// A leading backslash escapes '#' or '!'
+	line = strings.TrimPrefix(line, "\\")
+
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	// A slash anywhere but the end anchors the pattern to its .gitignore
+	if strings.Contains(line, "/") {
+		pattern.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	if line == "" {
+		return gitignorePattern{}, false
+	}
+
+	pattern.glob = line
+	return pattern, true
+}
+
+// Match reports whether the path (slash-separated, relative to the analysis
+// root) is ignored. Later patterns take precedence, and '!' patterns
+// re-include paths excluded by earlier ones.
+func (g *GitIgnore) Match(relPath string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+
+	ignored := false
+	for _, pattern := range g.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+
+		// Patterns only apply below the directory of their .gitignore
+		rel := relPath
+		if pattern.base != "" {
+			if !strings.HasPrefix(relPath, pattern.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(relPath, pattern.base+"/")
+		}
+
+		var matched bool
+		if pattern.anchored {
+			matched = fsutil.MatchGlob(pattern.glob, rel)
+		} else {
+			matched, _ = path.Match(pattern.glob, path.Base(rel))
+		}
+
+		if matched {
+			ignored = !pattern.negate
+		}
+	}
+
+	return ignored
+}
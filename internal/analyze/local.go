@@ -1,31 +1,111 @@
 package analyze
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pyzamo/chassis/internal/parse"
 )
 
 // LocalAnalyzer analyzes local directory structures
 type LocalAnalyzer struct {
+	Workers  int          // Maximum concurrent directory walks (1 or less means sequential)
+	Progress ProgressFunc // Optional progress callback, called every ProgressInterval items
+
+	// FollowSymlinks descends into symlinked directories. When false,
+	// symlinks are recorded as-is without being followed.
+	FollowSymlinks bool
+
+	// UseGitignore skips entries matched by .gitignore files found in the
+	// source root and its subdirectories
+	UseGitignore bool
+
+	// MaxNodes stops the walk once this many items have been scanned,
+	// returning a partial result (0 means no limit)
+	MaxNodes int
+
+	// NoFilter keeps everything, including dependencies, build outputs and
+	// dotfiles that the default Filter drops
+	NoFilter bool
+
+	// KeepDotfiles are more dotfiles for the filter to keep (see
+	// FilterOptions)
+	KeepDotfiles []string
+
+	// KeepLockfiles keeps dependency lock files such as go.sum
+	KeepLockfiles bool
+
+	// KeepHiddenDirs keeps dot-directories such as .github (see
+	// FilterOptions)
+	KeepHiddenDirs bool
+
+	// OnFiltered, if set, is told about each item left out and why. With
+	// more than one worker it is called from several goroutines, one call
+	// at a time.
+	OnFiltered FilteredFunc
+
 	sourcePath string
 	maxDepth   int
-	filter     *Filter
 }
 
 // NewLocalAnalyzer creates a new local directory analyzer
 func NewLocalAnalyzer(sourcePath string, maxDepth int) *LocalAnalyzer {
 	return &LocalAnalyzer{
+		Workers:    1,
 		sourcePath: sourcePath,
 		maxDepth:   maxDepth,
-		filter:     NewFilter(),
 	}
 }
 
-// Analyze performs the analysis of the local directory
-func (a *LocalAnalyzer) Analyze() (*Result, error) {
+// activeFilter returns the filter to walk with, or nil when filtering is off
+func (a *LocalAnalyzer) activeFilter() *Filter {
+	if a.NoFilter {
+		return nil
+	}
+	return NewFilterWithOptions(FilterOptions{
+		KeepDotfiles:   a.KeepDotfiles,
+		KeepLockfiles:  a.KeepLockfiles,
+		KeepHiddenDirs: a.KeepHiddenDirs,
+	})
+}
+
+// Analyze performs the analysis of the local directory, assembling the
+// nodes found by AnalyzeStream into a tree
+func (a *LocalAnalyzer) Analyze(ctx context.Context) (*Result, error) {
+	var root *parse.Node
+	byPath := make(map[string]*parse.Node)
+
+	// Each directory is listed by a single walker, so siblings arrive in
+	// os.ReadDir order and the tree is deterministic
+	result, err := a.AnalyzeStream(ctx, func(node *parse.Node) error {
+		byPath[node.Path] = node
+		if root == nil {
+			root = node
+			return nil
+		}
+		parent := byPath[filepath.Dir(node.Path)]
+		parent.Children = append(parent.Children, node)
+		return nil
+	})
+	if result == nil {
+		return nil, err
+	}
+
+	result.Nodes = []*parse.Node{root}
+	return result, err
+}
+
+// AnalyzeStream walks the local directory, passing each node to emit as it
+// is found. With more than one worker, emit is called from several
+// goroutines, one call at a time. Cancelling ctx stops the walk, returning
+// the counts so far with the context's error.
+func (a *LocalAnalyzer) AnalyzeStream(ctx context.Context, emit NodeFunc) (*Result, error) {
 	// Check if source exists
 	info, err := os.Stat(a.sourcePath)
 	if err != nil {
@@ -44,86 +124,242 @@ func (a *LocalAnalyzer) Analyze() (*Result, error) {
 		baseName = filepath.Base(absPath)
 	}
 
-	result := &Result{
-		Nodes: []*parse.Node{},
-	}
-
-	// Create root node
-	rootNode := &parse.Node{
-		Name:  baseName,
-		IsDir: true,
-		Path:  baseName,
-	}
+	result := &Result{}
 
 	// Walk the directory tree
 	walkResult := &walkResult{
-		filter: a.filter,
-		result: result,
+		ctx:        ctx,
+		filter:     a.activeFilter(),
+		emit:       emit,
+		onFiltered: a.OnFiltered,
+	}
+	if a.Workers > 1 {
+		walkResult.sem = make(chan struct{}, a.Workers-1)
+	}
+	if a.FollowSymlinks {
+		walkResult.visited = make(map[string]bool)
+		walkResult.visit(a.sourcePath)
 	}
 
-	if err := a.walkDirectory(a.sourcePath, rootNode, 1, walkResult); err != nil {
+	// The root comes first
+	if err := walkResult.send(&parse.Node{Name: baseName, IsDir: true, Path: baseName, ModTime: info.ModTime()}); err != nil {
 		return nil, err
 	}
 
-	// Only add root if it has children or we're analyzing an empty directory
-	if len(rootNode.Children) > 0 || (result.DirCount == 0 && result.FileCount == 0) {
-		result.Nodes = append(result.Nodes, rootNode)
-		result.DirCount++ // Count the root directory
+	a.walkDirectory(a.sourcePath, baseName, 1, walkResult, nil)
+	walkResult.wg.Wait()
+	if walkResult.err != nil {
+		return nil, walkResult.err
+	}
+
+	result.DirCount = int(walkResult.dirCount.Load())
+	result.FileCount = int(walkResult.fileCount.Load())
+	result.TotalSize = walkResult.totalSize.Load()
+	result.FilteredCount = int(walkResult.filteredCount.Load())
+	result.TotalScanned = int(walkResult.totalScanned.Load())
+	if walkResult.truncated.Load() {
+		result.Truncated = true
+		result.TruncatedBy = fmt.Sprintf("stopped after %d items", a.MaxNodes)
+	}
+
+	result.DirCount++ // Count the root directory
+
+	if err := ctx.Err(); err != nil {
+		result.Truncated = true
+		result.TruncatedBy = "cancelled"
+		return result, err
 	}
 
 	return result, nil
 }
 
-// walkResult holds state during directory walking
+// walkResult holds state during directory walking, shared by all workers
 type walkResult struct {
+	ctx    context.Context // Stops the walk when done
 	filter *Filter
-	result *Result
+
+	dirCount      atomic.Int64
+	fileCount     atomic.Int64
+	totalSize     atomic.Int64
+	filteredCount atomic.Int64
+	totalScanned  atomic.Int64
+	truncated     atomic.Bool
+
+	sem chan struct{}  // Limits extra walker goroutines (nil when sequential)
+	wg  sync.WaitGroup // Tracks walker goroutines
+
+	mu         sync.Mutex      // Guards visited, emit, onFiltered and err
+	visited    map[string]bool // Resolved directories already walked (only when following symlinks)
+	emit       NodeFunc        // Receives each node found
+	onFiltered FilteredFunc    // Told about each item filtered out (may be nil)
+	err        error           // First error returned by emit; stops the walk
+}
+
+// skip counts a filtered item, reporting it to onFiltered if set
+func (w *walkResult) skip(nodePath, reason string) {
+	w.filteredCount.Add(1)
+	if w.onFiltered == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onFiltered(nodePath, reason)
+}
+
+// send passes a node to emit, one call at a time. Once emit has failed no
+// more nodes are sent and the error is returned.
+func (w *walkResult) send(node *parse.Node) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = w.emit(node)
+	}
+	return w.err
+}
+
+// visit marks a directory as walked, returning false if it was already
+// visited. Directories are keyed by their resolved path so that symlink
+// cycles are detected.
+func (w *walkResult) visit(dirPath string) bool {
+	realPath, err := filepath.EvalSymlinks(dirPath)
+	if err != nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visited[realPath] {
+		return false
+	}
+	w.visited[realPath] = true
+	return true
 }
 
-// walkDirectory recursively walks the directory tree
-func (a *LocalAnalyzer) walkDirectory(dirPath string, parentNode *parse.Node, currentDepth int, walkResult *walkResult) error {
+// walkDirectory recursively walks the directory tree, emitting the entries
+// of dirPath in os.ReadDir order (sorted by name). parentPath is the Path
+// of the directory's node. Subdirectories may be walked concurrently.
+func (a *LocalAnalyzer) walkDirectory(dirPath, parentPath string, currentDepth int, walkResult *walkResult, ignore *GitIgnore) {
 	if currentDepth > a.maxDepth {
-		return nil // Stop at max depth
+		return // Stop at max depth
 	}
 
 	// Read directory contents
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		// Skip directories we can't read (permissions, etc.)
-		return nil
+		return
+	}
+
+	// Pick up this directory's .gitignore patterns
+	relDir := ""
+	if a.UseGitignore {
+		relDir = a.relativePath(dirPath)
+		ignore = ignore.LoadGitIgnore(dirPath, relDir)
 	}
 
 	for _, entry := range entries {
+		if walkResult.ctx.Err() != nil {
+			return
+		}
+
 		name := entry.Name()
 		fullPath := filepath.Join(dirPath, name)
-		walkResult.result.TotalScanned++
+		scanned := walkResult.totalScanned.Add(1)
+		if a.MaxNodes > 0 && scanned > int64(a.MaxNodes) {
+			// Node limit reached, stop with a partial result
+			walkResult.totalScanned.Add(-1)
+			walkResult.truncated.Store(true)
+			return
+		}
+		if a.Progress != nil && scanned%ProgressInterval == 0 {
+			a.Progress(int(scanned))
+		}
+
+		// Resolve symlinks to their target type when following them
+		isDir := entry.IsDir()
+		isSymlink := entry.Type()&os.ModeSymlink != 0
+		var size int64
+		var modTime time.Time
+		if isSymlink && a.FollowSymlinks {
+			if info, err := os.Stat(fullPath); err == nil {
+				isDir = info.IsDir()
+				size = info.Size()
+				modTime = info.ModTime()
+			}
+		} else if info, err := entry.Info(); err == nil {
+			size = info.Size()
+			modTime = info.ModTime()
+		}
+
+		nodePath := filepath.Join(parentPath, name)
 
 		// Check if should filter
-		if walkResult.filter.ShouldFilter(name, entry.IsDir()) {
-			walkResult.result.FilteredCount++
+		if filtered, reason := walkResult.filter.Explain(name, isDir); filtered {
+			walkResult.skip(nodePath, reason)
 			continue
 		}
 
-		// Create node
-		node := &parse.Node{
-			Name:  name,
-			IsDir: entry.IsDir(),
-			Path:  filepath.Join(parentNode.Path, name),
+		// Check if ignored by git
+		if a.UseGitignore && ignore.Match(path.Join(relDir, name), isDir) {
+			walkResult.skip(nodePath, "matched .gitignore")
+			continue
 		}
 
-		// Add to parent
-		parentNode.Children = append(parentNode.Children, node)
+		// Emit the node, stopping if the receiver has failed
+		node := &parse.Node{
+			Name:    name,
+			IsDir:   isDir,
+			Path:    nodePath,
+			ModTime: modTime,
+		}
+		if !isDir {
+			node.Size = size
+		}
+		if walkResult.send(node) != nil {
+			return
+		}
 
-		if entry.IsDir() {
-			walkResult.result.DirCount++
-			// Recursively walk subdirectory
-			if err := a.walkDirectory(fullPath, node, currentDepth+1, walkResult); err != nil {
-				return err
+		if isDir {
+			walkResult.dirCount.Add(1)
+			// Don't descend into a directory twice (symlink cycles)
+			if a.FollowSymlinks && !walkResult.visit(fullPath) {
+				continue
 			}
+			// Recursively walk subdirectory, on another worker if one is free
+			a.walkSubdirectory(fullPath, node.Path, currentDepth+1, walkResult, ignore)
 		} else {
-			walkResult.result.FileCount++
+			walkResult.fileCount.Add(1)
+			walkResult.totalSize.Add(size)
+		}
+	}
+}
+
+// walkSubdirectory walks a subdirectory on a free worker, or inline when
+// all workers are busy
+func (a *LocalAnalyzer) walkSubdirectory(dirPath, nodePath string, depth int, walkResult *walkResult, ignore *GitIgnore) {
+	if walkResult.sem != nil {
+		select {
+		case walkResult.sem <- struct{}{}:
+			walkResult.wg.Add(1)
+			go func() {
+				defer walkResult.wg.Done()
+				defer func() { <-walkResult.sem }()
+				a.walkDirectory(dirPath, nodePath, depth, walkResult, ignore)
+			}()
+			return
+		default:
 		}
 	}
 
-	return nil
+	a.walkDirectory(dirPath, nodePath, depth, walkResult, ignore)
+}
+
+// relativePath returns dirPath relative to the source root in slash form,
+// or "" for the root itself
+func (a *LocalAnalyzer) relativePath(dirPath string) string {
+	rel, err := filepath.Rel(a.sourcePath, dirPath)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
 }
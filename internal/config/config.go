@@ -0,0 +1,186 @@
+// Package config reads the .chassis.yaml file that supplies default flag
+// values
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file looked up in the working
+// directory and the home directory
+const FileName = ".chassis.yaml"
+
+// UserFileName is the name of the config file in the user's config dir
+const UserFileName = "config.yaml"
+
+// StubsKey is the top-level key holding file content templates rather than
+// flag values
+const StubsKey = "stubs"
+
+// File holds the flag defaults read from a config file. Top-level scalar
+// keys set global flags; top-level mappings set the flags of the command
+// they are named after:
+//
+//	verbose: true
+//	analyze:
+//	  max-depth: 3
+//	build:
+//	  indent: 4
+//	  var: [author=me, license=MIT]
+//
+// The stubs section maps file extensions to the content given to new empty
+// files by build --stub:
+//
+//	stubs:
+//	  .go: "package {{dir}}\n"
+type File struct {
+	Path     string                         // Where the file was read from
+	Global   map[string][]string            // Global flag name to values
+	Commands map[string]map[string][]string // Command name to flag name to values
+	Stubs    map[string]string              // File extension (with the dot) to content template
+}
+
+// SearchPaths returns the places a config file is looked for, in order:
+// ./.chassis.yaml, chassis/config.yaml under the user's config dir
+// ($XDG_CONFIG_HOME or ~/.config on Linux), then ~/.chassis.yaml
+func SearchPaths() []string {
+	paths := []string{FileName}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "chassis", UserFileName))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, FileName))
+	}
+	return paths
+}
+
+// Find returns the first existing file from SearchPaths, or "" when there
+// is none
+func Find() string {
+	for _, path := range SearchPaths() {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Load reads and parses the config file at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	file := &File{
+		Path:     path,
+		Global:   make(map[string][]string),
+		Commands: make(map[string]map[string][]string),
+	}
+
+	for key, value := range raw {
+		if key == StubsKey {
+			stubs, err := stubTemplates(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+			file.Stubs = stubs
+			continue
+		}
+
+		section, ok := value.(map[string]interface{})
+		if !ok {
+			values, err := flagValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+			file.Global[key] = values
+			continue
+		}
+
+		flags := make(map[string][]string)
+		for name, value := range section {
+			values, err := flagValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s.%s: %w", path, key, name, err)
+			}
+			flags[name] = values
+		}
+		file.Commands[key] = flags
+	}
+
+	return file, nil
+}
+
+// Keys returns the names in a section, sorted
+func Keys(section map[string][]string) []string {
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stubTemplates converts the stubs section to a map keyed by extension,
+// adding the leading dot when it is left out
+func stubTemplates(value interface{}) (map[string]string, error) {
+	section, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must map file extensions to templates")
+	}
+
+	stubs := make(map[string]string, len(section))
+	for ext, template := range section {
+		text, ok := template.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: template must be a string", ext)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		stubs[ext] = text
+	}
+	return stubs, nil
+}
+
+// flagValues converts a YAML value to flag values: a scalar gives one value
+// and a list of scalars gives one per item (for repeatable flags)
+func flagValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if !isScalar(item) {
+				return nil, fmt.Errorf("list items must be plain values")
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	default:
+		if !isScalar(v) {
+			return nil, fmt.Errorf("value must be a plain value or a list")
+		}
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// isScalar reports whether a decoded YAML value is a string, number or bool
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return true
+	}
+	return false
+}
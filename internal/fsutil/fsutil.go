@@ -4,7 +4,9 @@ package fsutil
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +16,66 @@ const (
 	FilePerm = 0644 // rw-r--r--
 )
 
+// ParseFileMode parses an octal permission string such as "755" or "0644".
+// Only permission bits (0000-0777) are accepted.
+func ParseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q: must be an octal number", s)
+	}
+	if mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: must be between 0000 and 0777", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// sizeUnits are the suffixes accepted by ParseSize, largest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a byte count such as "512", "64KB" or "10MB". Units are
+// powers of 1024 and case-insensitive; the "B" of "KB" may be left out.
+func ParseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q: must be a number of bytes, optionally with KB, MB or GB", s)
+	}
+	if n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return n * multiplier, nil
+}
+
+// FormatSize formats a byte count for display, such as "812 B" or "10.0 MB"
+func FormatSize(n int64) string {
+	for _, unit := range sizeUnits[:3] {
+		if n >= unit.bytes {
+			return fmt.Sprintf("%.1f %s", float64(n)/float64(unit.bytes), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
 // SafeMkdir creates a directory if it doesn't exist
 func SafeMkdir(path string, perm os.FileMode) error {
 	// Check if directory already exists
@@ -85,10 +147,12 @@ func SanitizePath(basePath, userPath string) (string, error) {
 		return "", fmt.Errorf("absolute paths not allowed: %s", userPath)
 	}
 
-	// Check for path traversal attempts
-	// Only reject if path contains "../" or "/.." or is exactly ".."
-	if cleanUserPath == ".." || strings.Contains(cleanUserPath, "../") || strings.Contains(cleanUserPath, "/..") || strings.HasSuffix(cleanUserPath, "/..") {
-		return "", fmt.Errorf("path traversal not allowed: %s", userPath)
+	// Check for path traversal attempts: a ".." component, not names that
+	// merely contain two dots such as "..config"
+	for _, part := range strings.Split(filepath.ToSlash(cleanUserPath), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("path traversal not allowed: %s", userPath)
+		}
 	}
 
 	// Join with base path and clean again
@@ -106,8 +170,11 @@ func SanitizePath(basePath, userPath string) (string, error) {
 		return "", fmt.Errorf("failed to resolve full path: %w", err)
 	}
 
-	// Ensure the resolved path is within the base directory
-	if !strings.HasPrefix(absPath, absBase) {
+	// Ensure the resolved path is within the base directory. Comparing via
+	// Rel respects path boundaries, so a sibling such as "/base-other" is
+	// not mistaken for a child of "/base".
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
 		return "", fmt.Errorf("path escapes target directory: %s", userPath)
 	}
 
@@ -130,3 +197,36 @@ func NormalizePath(path string) string {
 	// Clean the path
 	return filepath.Clean(normalized)
 }
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern.
+// In addition to the path.Match syntax, a "**" segment matches zero or more
+// path segments, so "src/**/*.go" matches both "src/main.go" and
+// "src/a/b/main.go".
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches pattern segments against path segments
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try consuming every possible number of segments
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
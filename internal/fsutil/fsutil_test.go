@@ -0,0 +1,70 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizePath(t *testing.T) {
+	base := t.TempDir()
+
+	tests := []struct {
+		name     string
+		userPath string
+		want     string // Expected result relative to base; ignored when wantErr
+		wantErr  bool
+	}{
+		{name: "child", userPath: "src/main.go", want: "src/main.go"},
+		{name: "exactly the base", userPath: ".", want: "."},
+		{name: "back into the base", userPath: "src/..", want: "."},
+		{name: "name starting with two dots", userPath: "src/..config", want: "src/..config"},
+		{name: "name ending with two dots", userPath: "src/notes..", want: "src/notes.."},
+		{name: "parent", userPath: "..", wantErr: true},
+		{name: "traversal", userPath: "src/../../etc/passwd", wantErr: true},
+		{name: "sibling with the base as prefix", userPath: "../" + filepath.Base(base) + "2/file", wantErr: true},
+		{name: "absolute", userPath: "/etc/passwd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizePath(base, tt.userPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SanitizePath(%q) = %q, want an error", tt.userPath, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SanitizePath(%q): %v", tt.userPath, err)
+			}
+			if want := filepath.Join(base, tt.want); got != want {
+				t.Errorf("SanitizePath(%q) = %q, want %q", tt.userPath, got, want)
+			}
+		})
+	}
+}
+
+func TestSanitizePathSymlinkedBase(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real")
+	if err := os.Mkdir(real, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	got, err := SanitizePath(link, "src/main.go")
+	if err != nil {
+		t.Fatalf("SanitizePath through a symlinked base: %v", err)
+	}
+	if want := filepath.Join(link, "src", "main.go"); got != want {
+		t.Errorf("SanitizePath = %q, want %q", got, want)
+	}
+
+	if _, err := SanitizePath(link, "../real/escape"); err == nil {
+		t.Error("SanitizePath allowed climbing out of a symlinked base")
+	}
+}
@@ -0,0 +1,310 @@
+// Package generalize turns an analyzed structure into a reusable skeleton
+// with simple rules, as an offline stand-in for the AI step
+package generalize
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// Options tunes how aggressively similar entries are collapsed
+type Options struct {
+	MinGroup  int  // Collapse groups of at least this many similar siblings (0 means 3)
+	Examples  int  // Keep this many examples of a collapsed group (0 means 2)
+	KeepNames bool // Leave the project's name in place instead of replacing it with NameVar
+}
+
+// NameVar is the placeholder that stands for the project's name, filled in
+// by chassis build --var name=...
+const NameVar = "{{name}}"
+
+// DefaultOptions are the options used by the analyze command
+var DefaultOptions = Options{MinGroup: 3, Examples: 2}
+
+// Generalize returns a copy of the layout in which runs of similar sibling
+// files, such as UserController.js and PostController.js, or directories
+// with the same contents, are collapsed to a few examples. A comment on the
+// first example says what it stands for ("# 5 files like *Controller.js").
+// Files are grouped by a shared suffix word, then by a shared prefix (a
+// number prefix counts as one), then, in larger runs, by extension.
+//
+// Unless KeepNames is set, each top-level directory is taken to be named
+// after the project (less any version, as in express-4.19.2), and that name
+// is replaced with NameVar in the directory's own name and wherever it is a
+// whole word of a name below it, so cmd/myapp/ and myapp_test.go become
+// cmd/{{name}}/ and {{name}}_test.go. The input is not modified.
+func Generalize(nodes []*parse.Node, options Options) []*parse.Node {
+	if options.MinGroup <= 0 {
+		options.MinGroup = DefaultOptions.MinGroup
+	}
+	if options.Examples <= 0 {
+		options.Examples = DefaultOptions.Examples
+	}
+	generalized := generalizeNodes(nodes, options)
+	if !options.KeepNames {
+		genericizeNames(generalized)
+	}
+	return generalized
+}
+
+// generalizeNodes copies the nodes, generalizing their children first so
+// that directories compare by their generalized contents
+func generalizeNodes(nodes []*parse.Node, options Options) []*parse.Node {
+	copied := make([]*parse.Node, 0, len(nodes))
+	for _, node := range nodes {
+		c := *node
+		if node.IsDir {
+			c.Children = generalizeNodes(node.Children, options)
+		}
+		copied = append(copied, &c)
+	}
+	return collapse(copied, options)
+}
+
+// groupPass groups siblings by a key, collapsing groups of at least min
+type groupPass struct {
+	key func(n *parse.Node) string // Group key, which is also the label; "" leaves the node alone
+	min int
+}
+
+// collapse applies each grouping pass in turn to a list of siblings. Nodes
+// already in a collapsed group are not grouped again.
+func collapse(nodes []*parse.Node, options Options) []*parse.Node {
+	passes := []groupPass{
+		{suffixPattern, options.MinGroup},
+		{prefixPattern, options.MinGroup},
+		{extensionPattern, 2 * options.MinGroup},
+		{dirShape, options.MinGroup},
+	}
+
+	grouped := make(map[*parse.Node]bool)
+	dropped := make(map[*parse.Node]bool)
+	for _, pass := range passes {
+		var keys []string
+		groups := make(map[string][]*parse.Node)
+		for _, node := range nodes {
+			if grouped[node] {
+				continue
+			}
+			key := pass.key(node)
+			if key == "" {
+				continue
+			}
+			if groups[key] == nil {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], node)
+		}
+
+		for _, key := range keys {
+			group := groups[key]
+			if len(group) < pass.min {
+				continue
+			}
+			for i, node := range group {
+				grouped[node] = true
+				if i >= options.Examples {
+					dropped[node] = true
+				}
+			}
+			group[0].Comment += groupComment(key, group)
+		}
+	}
+
+	kept := make([]*parse.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !dropped[node] {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// groupComment describes what a collapsed group's examples stand for
+func groupComment(key string, group []*parse.Node) string {
+	if group[0].IsDir {
+		return fmt.Sprintf("# %d directories with this layout\n", len(group))
+	}
+	return fmt.Sprintf("# %d files like %s\n", len(group), key)
+}
+
+// splitName splits a file name into its stem and extension, leaving names
+// without an extension (and dotfiles) unsplit
+func splitName(name string) (stem, ext string) {
+	ext = path.Ext(name)
+	if ext == name {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// isSeparator reports whether a byte separates words in a file name
+func isSeparator(b byte) bool {
+	return b == '_' || b == '-' || b == '.'
+}
+
+// wordStarts returns the offsets at which the words of a stem begin:
+// after a separator, or at an upper-case letter following a lower-case one
+// (camelCase)
+func wordStarts(stem string) []int {
+	starts := []int{0}
+	for i := 1; i < len(stem); i++ {
+		switch {
+		case isSeparator(stem[i-1]) && !isSeparator(stem[i]):
+			starts = append(starts, i)
+		case unicode.IsLower(rune(stem[i-1])) && unicode.IsUpper(rune(stem[i])):
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// groupableFile reports whether a file may be grouped with its siblings.
+// Dotfiles, files without an extension and all-caps names such as
+// README.md and LICENSE are kept as they are.
+func groupableFile(n *parse.Node) (stem, ext string, ok bool) {
+	if n.IsDir || strings.HasPrefix(n.Name, ".") {
+		return "", "", false
+	}
+	stem, ext = splitName(n.Name)
+	if ext == "" || strings.ToUpper(stem) == stem {
+		return "", "", false
+	}
+	return stem, ext, true
+}
+
+// suffixPattern groups files ending in the same word, such as
+// *Controller.js, *_test.go or *.spec.ts
+func suffixPattern(n *parse.Node) string {
+	stem, ext, ok := groupableFile(n)
+	if !ok {
+		return ""
+	}
+	starts := wordStarts(stem)
+	if len(starts) < 2 {
+		return ""
+	}
+	last := starts[len(starts)-1]
+	if !hasLetter(stem[last:]) {
+		return ""
+	}
+	if isSeparator(stem[last-1]) {
+		last--
+	}
+	return "*" + stem[last:] + ext
+}
+
+// prefixPattern groups files starting with the same word, such as test_*.py,
+// or with a number, as migrations do ([0-9]*_*.sql)
+func prefixPattern(n *parse.Node) string {
+	stem, ext, ok := groupableFile(n)
+	if !ok {
+		return ""
+	}
+	starts := wordStarts(stem)
+	if len(starts) < 2 {
+		return ""
+	}
+	first := stem[:starts[1]]
+	if word := strings.TrimRight(first, "_-."); word != "" && !hasLetter(word) {
+		// Numbered files, such as 001_init.sql and 002_users.sql
+		return "[0-9]*" + first[len(word):] + "*" + ext
+	}
+	return first + "*" + ext
+}
+
+// extensionPattern groups files by extension alone, such as *.png
+func extensionPattern(n *parse.Node) string {
+	if _, ext, ok := groupableFile(n); ok {
+		return "*" + ext
+	}
+	return ""
+}
+
+// dirShape groups directories with the same (generalized) contents. Empty
+// directories are kept apart, since their names are all they carry.
+func dirShape(n *parse.Node) string {
+	if !n.IsDir || len(n.Children) == 0 {
+		return ""
+	}
+	return "dir:" + shape(n)
+}
+
+// shape describes a directory's contents, recursively and in sorted order
+func shape(n *parse.Node) string {
+	entries := make([]string, 0, len(n.Children))
+	for _, child := range n.Children {
+		if child.IsDir {
+			entries = append(entries, child.Name+"/("+shape(child)+")")
+		} else {
+			entries = append(entries, child.Name)
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// hasLetter reports whether s contains a letter
+func hasLetter(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+// versionSuffix matches a version at the end of a directory name, as in
+// express-4.19.2 or tool_v2
+var versionSuffix = regexp.MustCompile(`[-_]v?[0-9]+(\.[0-9]+)*([-.+][0-9A-Za-z.]*)?$`)
+
+// genericNames are directory names that say nothing about the project, so
+// are not replaced when they are the top-level directory
+var genericNames = map[string]bool{
+	"app": true, "lib": true, "src": true, "pkg": true, "cmd": true,
+	"package": true, "project": true, "repo": true, "test": true, "tests": true,
+}
+
+// genericizeNames replaces the project's name, taken from each top-level
+// directory, with NameVar in the (already copied) nodes
+func genericizeNames(nodes []*parse.Node) {
+	for _, node := range nodes {
+		if !node.IsDir {
+			continue
+		}
+		project := versionSuffix.ReplaceAllString(node.Name, "")
+		if len(project) < 3 || genericNames[strings.ToLower(project)] {
+			continue
+		}
+
+		node.Name = NameVar
+		for _, child := range node.Children {
+			child.Walk(func(n *parse.Node) error {
+				n.Name = replaceWord(n.Name, project)
+				return nil
+			})
+		}
+	}
+}
+
+// replaceWord replaces word with NameVar wherever it appears in name
+// between separators (or the start, the end and the extension)
+func replaceWord(name, word string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(name, word)
+		if i < 0 {
+			b.WriteString(name)
+			return b.String()
+		}
+		end := i + len(word)
+		if (i == 0 || isSeparator(name[i-1])) && (end == len(name) || isSeparator(name[end])) {
+			b.WriteString(name[:i])
+			b.WriteString(NameVar)
+		} else {
+			b.WriteString(name[:end])
+		}
+		name = name[end:]
+	}
+}
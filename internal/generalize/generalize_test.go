@@ -0,0 +1,138 @@
+package generalize
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// files returns file nodes with the given names
+func files(names ...string) []*parse.Node {
+	nodes := make([]*parse.Node, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, &parse.Node{Name: name})
+	}
+	return nodes
+}
+
+// names returns the names of nodes, in order
+func names(nodes []*parse.Node) []string {
+	var result []string
+	for _, node := range nodes {
+		result = append(result, node.Name)
+	}
+	return result
+}
+
+func TestGeneralizeCollapsesSimilarFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []string
+		comment string
+	}{
+		{
+			name:    "suffix word",
+			files:   []string{"UserController.js", "PostController.js", "TagController.js", "app.js"},
+			want:    []string{"UserController.js", "PostController.js", "app.js"},
+			comment: "# 3 files like *Controller.js\n",
+		},
+		{
+			name:    "numbered prefix",
+			files:   []string{"001_init.sql", "002_users.sql", "003_posts.sql", "004_tags.sql"},
+			want:    []string{"001_init.sql", "002_users.sql"},
+			comment: "# 4 files like [0-9]*_*.sql\n",
+		},
+		{
+			name:  "too few to group",
+			files: []string{"UserController.js", "PostController.js"},
+			want:  []string{"UserController.js", "PostController.js"},
+		},
+		{
+			name:  "all-caps names kept",
+			files: []string{"README.md", "CHANGELOG.md", "CONTRIBUTING.md"},
+			want:  []string{"README.md", "CHANGELOG.md", "CONTRIBUTING.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Generalize(files(tt.files...), Options{KeepNames: true})
+			if !reflect.DeepEqual(names(got), tt.want) {
+				t.Errorf("Generalize = %v, want %v", names(got), tt.want)
+			}
+			if got[0].Comment != tt.comment {
+				t.Errorf("comment = %q, want %q", got[0].Comment, tt.comment)
+			}
+		})
+	}
+}
+
+func TestGeneralizeCollapsesMatchingDirectories(t *testing.T) {
+	var nodes []*parse.Node
+	for _, name := range []string{"users", "posts", "tags"} {
+		nodes = append(nodes, &parse.Node{Name: name, IsDir: true, Children: files("index.ts", "routes.ts")})
+	}
+	nodes = append(nodes, &parse.Node{Name: "shared", IsDir: true, Children: files("db.ts")})
+
+	got := Generalize(nodes, Options{KeepNames: true})
+	if want := []string{"users", "posts", "shared"}; !reflect.DeepEqual(names(got), want) {
+		t.Errorf("Generalize = %v, want %v", names(got), want)
+	}
+	if want := "# 3 directories with this layout\n"; got[0].Comment != want {
+		t.Errorf("comment = %q, want %q", got[0].Comment, want)
+	}
+}
+
+func TestGeneralizeReplacesProjectName(t *testing.T) {
+	tests := []struct {
+		root string
+		want string
+	}{
+		{root: "myapp", want: "{{name}}/ cmd/ {{name}}/ main.go myapp2.go {{name}}.go {{name}}_test.go"},
+		{root: "myapp-1.2.0", want: "{{name}}/ cmd/ {{name}}/ main.go myapp2.go {{name}}.go {{name}}_test.go"},
+		{root: "src", want: "src/ cmd/ myapp/ main.go myapp2.go myapp.go myapp_test.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.root, func(t *testing.T) {
+			root := &parse.Node{Name: tt.root, IsDir: true, Children: []*parse.Node{
+				{Name: "cmd", IsDir: true, Children: []*parse.Node{
+					{Name: "myapp", IsDir: true, Children: files("main.go")},
+				}},
+				{Name: "myapp2.go"},
+				{Name: "myapp.go"},
+				{Name: "myapp_test.go"},
+			}}
+
+			got := Generalize([]*parse.Node{root}, Options{})
+
+			var walked []string
+			got[0].Walk(func(n *parse.Node) error {
+				name := n.Name
+				if n.IsDir {
+					name += "/"
+				}
+				walked = append(walked, name)
+				return nil
+			})
+			if strings.Join(walked, " ") != tt.want {
+				t.Errorf("names = %s, want %s", strings.Join(walked, " "), tt.want)
+			}
+			if root.Name != tt.root || root.Children[1].Name != "myapp2.go" || root.Children[2].Name != "myapp.go" {
+				t.Errorf("input modified: root %s, children %v", root.Name, names(root.Children))
+			}
+		})
+	}
+}
+
+func TestGeneralizeKeepsInput(t *testing.T) {
+	nodes := files("a_test.go", "b_test.go", "c_test.go")
+	Generalize(nodes, DefaultOptions)
+
+	if len(nodes) != 3 || nodes[0].Comment != "" {
+		t.Errorf("input modified: %v, first comment %q", names(nodes), nodes[0].Comment)
+	}
+}
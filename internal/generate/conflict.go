@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// conflictChoice is the answer given when a file already exists
+type conflictChoice int
+
+const (
+	choiceNone conflictChoice = iota
+	choiceSkip
+	choiceOverwrite
+)
+
+// conflictPrompt asks the user how to handle existing files
+type conflictPrompt struct {
+	mu       sync.Mutex     // Serializes prompts between workers
+	input    *bufio.Reader  // Source of answers
+	output   io.Writer      // Destination for the prompt text
+	remember conflictChoice // Answer chosen with "all", applied to the rest of the run
+}
+
+// newConflictPrompt creates a prompt reading answers from input
+func newConflictPrompt(input io.Reader) *conflictPrompt {
+	if input == nil {
+		input = os.Stdin
+	}
+	return &conflictPrompt{
+		input:  bufio.NewReader(input),
+		output: os.Stderr,
+	}
+}
+
+// resolve asks whether the existing file at path should be overwritten.
+// Answers: o (overwrite), s (skip), O (overwrite all), S (skip all).
+// End of input skips this and all remaining files.
+func (p *conflictPrompt) resolve(path string) conflictChoice {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.remember != choiceNone {
+		return p.remember
+	}
+
+	for {
+		fmt.Fprintf(p.output, "%s already exists. [o]verwrite, [s]kip, [O]verwrite all, [S]kip all? ", path)
+
+		answer, err := p.input.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+
+		switch answer {
+		case "o", "overwrite":
+			return choiceOverwrite
+		case "s", "skip":
+			return choiceSkip
+		case "O", "overwrite-all":
+			p.remember = choiceOverwrite
+			return choiceOverwrite
+		case "S", "skip-all":
+			p.remember = choiceSkip
+			return choiceSkip
+		}
+
+		if err != nil {
+			// No more input: skip everything from here on
+			fmt.Fprintln(p.output)
+			p.remember = choiceSkip
+			return choiceSkip
+		}
+	}
+}
+
+// overwriteFile replaces the contents of an existing file with the node's
+// content. A node with no content (nor stub) leaves the file as it is.
+func (g *Generator) overwriteFile(node *parse.Node, fullPath string) error {
+	// Leave the existing file alone if the new content is refused
+	content, hasContent, err := g.fileContent(node, fullPath)
+	if err == nil {
+		err = g.checkSize(content)
+	}
+	if err != nil {
+		return g.fail(OpOverwriteFile, fullPath, err)
+	}
+
+	// Overwriting with nothing would only empty the file, so keep it
+	if !hasContent {
+		g.recordSkipped(fullPath)
+		g.report(Event{Warning: true, Action: ActionSkip, Path: fullPath, Reason: "no content to overwrite it with"})
+		return nil
+	}
+
+	g.acquire()
+	defer g.release()
+
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_TRUNC, g.fileMode())
+	if err != nil {
+		return g.fail(OpOverwriteFile, fullPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		return g.fail(OpWriteFile, fullPath, err)
+	}
+
+	g.report(Event{Action: ActionOverwrite, Path: fullPath})
+	g.recordOverwritten(fullPath)
+	return nil
+}
+
+// recordOverwritten records an existing file that was overwritten
+func (g *Generator) recordOverwritten(path string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.result.Overwritten++
+	g.result.OverwrittenPaths = append(g.result.OverwrittenPaths, path)
+}
@@ -0,0 +1,32 @@
+package generate
+
+import "fmt"
+
+// Operations reported in a GenerateError
+const (
+	OpResolvePath    = "resolve path"
+	OpCreateDir      = "create directory"
+	OpCreateParent   = "create parent directory for"
+	OpCreateFile     = "create file"
+	OpWriteFile      = "write file"
+	OpOverwriteFile  = "overwrite file"
+	OpMakeExecutable = "make executable"
+	OpSetModTime     = "set modification time of"
+)
+
+// GenerateError describes a failed filesystem operation on one path
+type GenerateError struct {
+	Path string // Path the operation was applied to
+	Op   string // Operation that failed (one of the Op constants)
+	Err  error  // Underlying cause
+}
+
+// Error implements the error interface
+func (e *GenerateError) Error() string {
+	return fmt.Sprintf("failed to %s %s: %v", e.Op, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying cause, for errors.Is and errors.As
+func (e *GenerateError) Unwrap() error {
+	return e.Err
+}
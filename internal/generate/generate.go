@@ -2,10 +2,15 @@
 package generate
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pyzamo/chassis/internal/fsutil"
 	"github.com/pyzamo/chassis/internal/parse"
@@ -13,26 +18,69 @@ import (
 
 // Result contains statistics about the generation process
 type Result struct {
-	Created      int      // Number of files/directories created
-	Skipped      int      // Number of files/directories skipped (already exist)
-	Errors       []string // Any errors encountered
-	CreatedPaths []string // List of successfully created paths
-	SkippedPaths []string // List of skipped paths
+	Created      int              `json:"created"`       // Number of files/directories created
+	Skipped      int              `json:"skipped"`       // Number of files/directories skipped (already exist)
+	Errors       []string         `json:"errors"`        // Any errors encountered
+	Failures     []*GenerateError `json:"-"`             // The errors with their path, operation and cause
+	CreatedPaths []string         `json:"created_paths"` // List of successfully created paths
+	SkippedPaths []string         `json:"skipped_paths"` // List of skipped paths
+	RolledBack   int              `json:"rolled_back"`   // Number of created paths removed after a failure
+
+	Overwritten      int      `json:"overwritten"`       // Number of existing files overwritten
+	OverwrittenPaths []string `json:"overwritten_paths"` // List of overwritten paths
 }
 
 // Options configures the generation process
 type Options struct {
-	TargetDir string // Target directory for generation
-	Verbose   bool   // Print detailed output
-	DryRun    bool   // Preview changes without creating files (future enhancement)
-	Force     bool   // Overwrite existing files (future enhancement)
+	TargetDir   string // Target directory for generation
+	Verbose     bool   // Print detailed output
+	DryRun      bool   // Preview changes without creating files (future enhancement)
+	Force       bool   // Overwrite existing files (future enhancement)
+	Concurrency int    // Maximum concurrent filesystem operations (1 or less means sequential)
+
+	DirMode  os.FileMode // Permissions for created directories (0 means fsutil.DirPerm)
+	FileMode os.FileMode // Permissions for created files (0 means fsutil.FilePerm)
+
+	RollbackOnError bool // Remove the paths created by this run if generation fails
+	FailFast        bool // Stop the whole build at the first error instead of continuing with other nodes
+	EmptyOnly       bool // Refuse to generate into a target directory that has entries
+
+	Sorted    bool // Generate in sorted order (directories first, then alphabetical), without reordering the given nodes
+	DirsOnly  bool // Create directories only, skipping every file
+	FilesOnly bool // Create files only; parents are made as needed but empty directories are not
+
+	// MaxFileSize is the largest content, in bytes, written to one file (0
+	// means no limit)
+	MaxFileSize int64
+
+	// Stubs maps file extensions (".go") to content templates for files the
+	// layout gives no content (nil leaves them empty; see DefaultStubs)
+	Stubs map[string]string
+
+	// PreserveModTime gives created paths the ModTime of their node, for
+	// nodes that come from an analysis (nodes without one are left alone)
+	PreserveModTime bool
+
+	Interactive bool      // Ask whether to overwrite each existing file
+	Input       io.Reader // Source of interactive answers (defaults to os.Stdin)
 }
 
 // Generator handles the filesystem generation
 type Generator struct {
 	options Options
 	result  *Result
+	ctx     context.Context // Cancels the build between nodes
 	logger  Logger
+	target  string        // Resolved absolute target directory
+	mu      sync.Mutex    // Guards result
+	dirMu   sync.Mutex    // Serializes ensureParents
+	sem     chan struct{} // Limits concurrent operations (nil when sequential)
+	prompt  *conflictPrompt
+	stopped bool // Set by fail in fail-fast mode; guarded by mu
+
+	// targetCreated lists the target and its parents when this run made them,
+	// outermost first. They are not layout paths, so they are not in Result.
+	targetCreated []string
 }
 
 // Logger interface for output
@@ -43,94 +91,362 @@ type Logger interface {
 	Error(format string, args ...interface{})
 }
 
+// Actions reported in an Event
+const (
+	ActionCreate    = "create"
+	ActionSkip      = "skip"
+	ActionOverwrite = "overwrite"
+	ActionRollback  = "rollback"
+	ActionFail      = "fail" // An operation failed; Reason names it (see the Op constants)
+)
+
+// Event describes one action the generator took, or failed to take, on a path
+type Event struct {
+	Warning bool   // True for events the user should notice, such as an existing path skipped
+	Action  string // One of the Action constants
+	Path    string // Absolute path, with a trailing slash for directories
+	Reason  string // Why a path was skipped, if it was
+	Err     error  // Why the action failed, if it did
+}
+
+// String renders the event as a progress message, such as
+// "SKIP: /tmp/app/go.mod (already exists)"
+func (e Event) String() string {
+	switch {
+	case e.Err != nil && e.Reason != "":
+		return fmt.Sprintf("%s: %s (%s: %v)", strings.ToUpper(e.Action), e.Path, e.Reason, e.Err)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %s (failed: %v)", strings.ToUpper(e.Action), e.Path, e.Err)
+	case e.Reason != "":
+		return fmt.Sprintf("%s: %s (%s)", strings.ToUpper(e.Action), e.Path, e.Reason)
+	default:
+		return fmt.Sprintf("%s: %s", strings.ToUpper(e.Action), e.Path)
+	}
+}
+
+// EventLogger is a Logger that takes the generator's actions as typed
+// events. Other loggers get each event as a message, at Warning level for
+// warnings and Verbose otherwise; failures are left to the Result, whose
+// summary lists them.
+type EventLogger interface {
+	Logger
+	Event(e Event)
+}
+
+// report passes an event to the logger
+func (g *Generator) report(e Event) {
+	if logger, ok := g.logger.(EventLogger); ok {
+		logger.Event(e)
+		return
+	}
+	if e.Action == ActionFail {
+		return
+	}
+	if e.Warning {
+		g.logger.Warning("%s", e)
+	} else {
+		g.logger.Verbose("%s", e)
+	}
+}
+
 // ConsoleLogger implements Logger for console output
 type ConsoleLogger struct {
 	VerboseMode bool
+	Quiet       bool      // Only print errors
+	Stdout      io.Writer // Destination for non-error output (defaults to os.Stdout)
+	Stderr      io.Writer // Destination for errors (defaults to os.Stderr)
+}
+
+// stdout returns the writer for non-error output
+func (l *ConsoleLogger) stdout() io.Writer {
+	if l.Stdout != nil {
+		return l.Stdout
+	}
+	return os.Stdout
+}
+
+// stderr returns the writer for errors
+func (l *ConsoleLogger) stderr() io.Writer {
+	if l.Stderr != nil {
+		return l.Stderr
+	}
+	return os.Stderr
 }
 
 func (l *ConsoleLogger) Info(format string, args ...interface{}) {
-	fmt.Printf(format+"\n", args...)
+	if l.Quiet {
+		return
+	}
+	fmt.Fprintf(l.stdout(), format+"\n", args...)
 }
 
 func (l *ConsoleLogger) Verbose(format string, args ...interface{}) {
-	if l.VerboseMode {
-		fmt.Printf("[VERBOSE] "+format+"\n", args...)
+	if l.VerboseMode && !l.Quiet {
+		fmt.Fprintf(l.stdout(), "[VERBOSE] "+format+"\n", args...)
 	}
 }
 
 func (l *ConsoleLogger) Warning(format string, args ...interface{}) {
-	fmt.Printf("[WARNING] "+format+"\n", args...)
+	if l.Quiet {
+		return
+	}
+	fmt.Fprintf(l.stdout(), "[WARNING] "+format+"\n", args...)
 }
 
 func (l *ConsoleLogger) Error(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "[ERROR] "+format+"\n", args...)
+	fmt.Fprintf(l.stderr(), "[ERROR] "+format+"\n", args...)
 }
 
-// Generate creates the filesystem structure from the parsed nodes
-func Generate(nodes []*parse.Node, targetDir string, verbose bool) (*Result, error) {
-	gen := &Generator{
-		options: Options{
-			TargetDir: targetDir,
-			Verbose:   verbose,
-		},
-		result: &Result{
-			Errors:       []string{},
-			CreatedPaths: []string{},
-			SkippedPaths: []string{},
-		},
-		logger: &ConsoleLogger{VerboseMode: verbose},
+// Generate creates the filesystem structure from the parsed nodes, reporting
+// progress to logger (a default ConsoleLogger if nil)
+func Generate(nodes []*parse.Node, targetDir string, logger Logger) (*Result, error) {
+	if logger == nil {
+		logger = &ConsoleLogger{}
 	}
 
+	gen := NewGenerator(Options{
+		TargetDir: targetDir,
+	}, logger)
+
 	return gen.Generate(nodes)
 }
 
+// NewGenerator creates a new generator with the given options and logger
+func NewGenerator(options Options, logger Logger) *Generator {
+	var sem chan struct{}
+	if options.Concurrency > 1 {
+		sem = make(chan struct{}, options.Concurrency)
+	}
+
+	var prompt *conflictPrompt
+	if options.Interactive {
+		prompt = newConflictPrompt(options.Input)
+	}
+
+	return &Generator{
+		sem:     sem,
+		prompt:  prompt,
+		ctx:     context.Background(),
+		options: options,
+		result: &Result{
+			Errors:           []string{},
+			CreatedPaths:     []string{},
+			SkippedPaths:     []string{},
+			OverwrittenPaths: []string{},
+		},
+		logger: logger,
+	}
+}
+
 // Generate creates the filesystem structure
 func (g *Generator) Generate(nodes []*parse.Node) (*Result, error) {
+	return g.GenerateContext(context.Background(), nodes)
+}
+
+// GenerateContext creates the filesystem structure like Generate, stopping
+// before the next node once ctx is cancelled. The partial result is returned
+// with an error that wraps the context's error.
+func (g *Generator) GenerateContext(ctx context.Context, nodes []*parse.Node) (*Result, error) {
+	g.ctx = ctx
+
 	// Ensure target directory exists
 	targetAbs, err := filepath.Abs(g.options.TargetDir)
 	if err != nil {
 		return g.result, fmt.Errorf("invalid target directory: %w", err)
 	}
 
-	if err := fsutil.SafeMkdir(targetAbs, fsutil.DirPerm); err != nil {
+	if err := g.createTarget(targetAbs); err != nil {
 		return g.result, fmt.Errorf("failed to create target directory: %w", err)
 	}
 
 	g.logger.Verbose("Target directory: %s", targetAbs)
+	g.target = targetAbs
+
+	if g.options.EmptyOnly {
+		if err := checkEmpty(targetAbs); err != nil {
+			return g.result, err
+		}
+	}
+
+	// Sort for a deterministic order independent of the layout, leaving
+	// the caller's layout in its own order
+	if g.options.Sorted {
+		nodes = sortedCopy(nodes)
+	}
 
 	// Process each root node
 	for _, node := range nodes {
-		if err := g.generateNode(node, targetAbs); err != nil {
-			g.result.Errors = append(g.result.Errors, err.Error())
-			// Continue processing other nodes even if one fails
+		// Failures are recorded where they happen; continue unless failing fast
+		if err := g.generateNode(node, targetAbs); err != nil && g.options.FailFast {
+			break
+		}
+	}
+
+	// Cancellation leaves a partial build, handled like a failed one
+	if err := ctx.Err(); err != nil {
+		created := g.result.Created
+		if g.options.RollbackOnError {
+			g.rollback()
 		}
+		return g.result, fmt.Errorf("generation cancelled after creating %d paths: %w", created, err)
 	}
 
 	// Check if there were any critical errors
 	if len(g.result.Errors) > 0 {
+		if g.options.RollbackOnError {
+			g.rollback()
+		}
 		return g.result, fmt.Errorf("generation completed with %d errors", len(g.result.Errors))
 	}
 
 	return g.result, nil
 }
 
+// displayPath returns the path for an event about node, with a trailing
+// slash for directories
+func displayPath(node *parse.Node, fullPath string) string {
+	if node.IsDir {
+		return fullPath + "/"
+	}
+	return fullPath
+}
+
+// sortedCopy returns the nodes sorted like parse.SortNodes at every level.
+// Directories are copied so that the input's children keep their order.
+func sortedCopy(nodes []*parse.Node) []*parse.Node {
+	sorted := make([]*parse.Node, len(nodes))
+	for i, node := range nodes {
+		if node.IsDir {
+			c := *node
+			c.Children = sortedCopy(node.Children)
+			node = &c
+		}
+		sorted[i] = node
+	}
+	parse.SortNodes(sorted)
+	return sorted
+}
+
+// checkEmpty returns an error naming a few of the entries in dir, if any
+func checkEmpty(dir string) error {
+	const maxListed = 5
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read target directory: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var names []string
+	for i, entry := range entries {
+		if i == maxListed {
+			names = append(names, fmt.Sprintf("and %d more", len(entries)-maxListed))
+			break
+		}
+		names = append(names, entry.Name())
+	}
+
+	return fmt.Errorf("target directory %s is not empty (contains %s)", dir, strings.Join(names, ", "))
+}
+
+// createTarget makes the target directory and any missing parents, one level
+// at a time, remembering the ones it made so rollback can remove them
+func (g *Generator) createTarget(target string) error {
+	var missing []string
+	for dir := target; !fsutil.PathExists(dir); dir = filepath.Dir(dir) {
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	if len(missing) == 0 {
+		return fsutil.SafeMkdir(target, g.dirMode())
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], g.dirMode()); err != nil && !os.IsExist(err) {
+			return err
+		}
+		g.targetCreated = append(g.targetCreated, missing[i])
+	}
+	return nil
+}
+
+// rollback removes the paths created by this run in reverse creation order,
+// so files and subdirectories are removed before their parents, and then the
+// target directory if this run made it. Skipped paths existed beforehand and
+// are never touched.
+func (g *Generator) rollback() {
+	paths := append([]string{}, g.targetCreated...)
+	paths = append(paths, g.result.CreatedPaths...)
+	for i := len(paths) - 1; i >= 0; i-- {
+		path := paths[i]
+		if err := os.Remove(path); err != nil {
+			g.report(Event{Warning: true, Action: ActionRollback, Path: path, Err: err})
+			continue
+		}
+		g.report(Event{Action: ActionRollback, Path: path})
+		g.result.RolledBack++
+	}
+}
+
 // generateNode recursively generates a node and its children
 func (g *Generator) generateNode(node *parse.Node, parentPath string) error {
-	fullPath := filepath.Join(parentPath, node.Name)
+	// Nothing more starts once a fail-fast build has failed or the build
+	// is cancelled
+	if g.isStopped() {
+		return nil
+	}
+
+	// Resolve the path relative to the target, rejecting names that escape it
+	userPath := node.Name
+	if relParent, err := filepath.Rel(g.target, parentPath); err == nil && relParent != "." {
+		userPath = relParent + string(filepath.Separator) + node.Name
+	}
+	fullPath, err := fsutil.SanitizePath(g.target, userPath)
+	if err != nil {
+		return g.fail(OpResolvePath, userPath, err)
+	}
+
+	// Leave files to another tool when only directories are wanted
+	if g.options.DirsOnly && !node.IsDir {
+		g.recordSkipped(fullPath)
+		g.report(Event{Action: ActionSkip, Path: fullPath, Reason: "--dirs-only"})
+		return nil
+	}
+
+	// Directories are only made as parents of files, when the files are created
+	if g.options.FilesOnly && node.IsDir {
+		if fsutil.PathExists(fullPath) && !fsutil.IsDirectory(fullPath) {
+			g.recordSkipped(fullPath)
+			g.report(Event{Warning: true, Action: ActionSkip, Path: fullPath, Reason: "already exists"})
+			return nil
+		}
+		return g.generateChildren(node.Children, fullPath)
+	}
+
+	// Limit concurrent filesystem operations; released before descending
+	g.acquire()
 
 	// Check if path exists
 	if fsutil.PathExists(fullPath) {
-		g.result.Skipped++
-		g.result.SkippedPaths = append(g.result.SkippedPaths, fullPath)
-		g.logger.Warning("SKIP: %s (already exists)", fullPath)
+		g.release()
+
+		// Let the user decide what happens to existing files
+		if g.prompt != nil && !node.IsDir && fsutil.IsFile(fullPath) {
+			if g.prompt.resolve(fullPath) == choiceOverwrite {
+				return g.overwriteFile(node, fullPath)
+			}
+		}
+
+		g.recordSkipped(fullPath)
+		g.report(Event{Warning: true, Action: ActionSkip, Path: displayPath(node, fullPath), Reason: "already exists"})
 
 		// If it's a directory and it exists, still process children
 		if node.IsDir {
-			for _, child := range node.Children {
-				if err := g.generateNode(child, fullPath); err != nil {
-					return err
-				}
-			}
+			return g.generateChildren(node.Children, fullPath)
 		}
 		return nil
 	}
@@ -138,59 +454,266 @@ func (g *Generator) generateNode(node *parse.Node, parentPath string) error {
 	// Create the path
 	if node.IsDir {
 		// Create directory
-		if err := fsutil.SafeMkdir(fullPath, fsutil.DirPerm); err != nil {
-			g.result.Errors = append(g.result.Errors, fmt.Sprintf("failed to create directory %s: %v", fullPath, err))
-			return fmt.Errorf("failed to create directory %s: %w", fullPath, err)
-		}
-		g.logger.Verbose("CREATE: %s/", fullPath)
-		g.result.Created++
-		g.result.CreatedPaths = append(g.result.CreatedPaths, fullPath)
-
-		// Process children
-		for _, child := range node.Children {
-			if err := g.generateNode(child, fullPath); err != nil {
-				return err
-			}
+		if err := fsutil.SafeMkdir(fullPath, g.dirMode()); err != nil {
+			g.release()
+			return g.fail(OpCreateDir, fullPath, err)
 		}
-	} else {
-		// Ensure parent directory exists
-		if err := fsutil.EnsureDir(fullPath); err != nil {
-			g.result.Errors = append(g.result.Errors, fmt.Sprintf("failed to create parent directory for %s: %v", fullPath, err))
-			return fmt.Errorf("failed to create parent directory for %s: %w", fullPath, err)
+		g.release()
+		g.report(Event{Action: ActionCreate, Path: fullPath + "/"})
+		g.recordCreated(fullPath)
+
+		// Process children, then set the time their creation changed
+		if err := g.generateChildren(node.Children, fullPath); err != nil {
+			return err
+		}
+		return g.setModTime(node, fullPath)
+	}
+
+	defer g.release()
+
+	// Refuse oversized content before touching the filesystem
+	content, hasContent, err := g.fileContent(node, fullPath)
+	if err == nil {
+		err = g.checkSize(content)
+	}
+	if err != nil {
+		return g.fail(OpWriteFile, fullPath, err)
+	}
+
+	// Ensure parent directory exists
+	if err := g.ensureParents(fullPath); err != nil {
+		return g.fail(OpCreateParent, fullPath, err)
+	}
+
+	// Create the file, empty unless the layout gave it content or a stub applies
+	file, err := fsutil.SafeCreateFile(fullPath, g.fileMode())
+	if err != nil {
+		// Check if it's because the file exists (race condition)
+		if strings.Contains(err.Error(), "already exists") {
+			g.recordSkipped(fullPath)
+			g.report(Event{Warning: true, Action: ActionSkip, Path: fullPath, Reason: "already exists"})
+			return nil
+		}
+		return g.fail(OpCreateFile, fullPath, err)
+	}
+	if hasContent {
+		if _, err := file.WriteString(content); err != nil {
+			file.Close()
+			return g.fail(OpWriteFile, fullPath, err)
+		}
+	}
+	file.Close()
+
+	// Add the execute bits for files marked executable
+	if node.Executable {
+		if err := os.Chmod(fullPath, g.fileMode()|0111); err != nil {
+			return g.fail(OpMakeExecutable, fullPath, err)
+		}
+	}
+
+	g.report(Event{Action: ActionCreate, Path: fullPath})
+	g.recordCreated(fullPath)
+
+	return g.setModTime(node, fullPath)
+}
+
+// generateChildren generates the children of a directory that already exists.
+// Siblings are generated concurrently when Concurrency is greater than 1. A
+// failing child doesn't stop its siblings unless FailFast is set; the first
+// error is returned either way.
+func (g *Generator) generateChildren(children []*parse.Node, parentPath string) error {
+	if g.sem == nil {
+		var firstErr error
+		for _, child := range children {
+			if err := g.generateNode(child, parentPath); err != nil {
+				if g.options.FailFast {
+					return err
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
 		}
+		return firstErr
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(children))
+	for i, child := range children {
+		wg.Add(1)
+		go func(i int, child *parse.Node) {
+			defer wg.Done()
+			errs[i] = g.generateNode(child, parentPath)
+		}(i, child)
+	}
+	wg.Wait()
 
-		// Create empty file
-		file, err := fsutil.SafeCreateFile(fullPath, fsutil.FilePerm)
+	// Report the first failure in layout order
+	for _, err := range errs {
 		if err != nil {
-			// Check if it's because the file exists (race condition)
-			if strings.Contains(err.Error(), "already exists") {
-				g.result.Skipped++
-				g.result.SkippedPaths = append(g.result.SkippedPaths, fullPath)
-				g.logger.Warning("SKIP: %s (already exists)", fullPath)
-				return nil
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureParents creates the missing directories between the target and
+// fullPath's parent one level at a time, with the directory mode, and records
+// each one as created so rollback removes it. This is how --files-only makes
+// the parents of its files.
+func (g *Generator) ensureParents(fullPath string) error {
+	rel, err := filepath.Rel(g.target, filepath.Dir(fullPath))
+	if err != nil || rel == "." {
+		return err
+	}
+
+	// Held across each mkdir and its record, so a directory another worker
+	// just made is recorded before anything is created inside it
+	g.dirMu.Lock()
+	defer g.dirMu.Unlock()
+
+	dir := g.target
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		dir = filepath.Join(dir, part)
+		err := os.Mkdir(dir, g.dirMode())
+		if os.IsExist(err) {
+			if !fsutil.IsDirectory(dir) {
+				return fmt.Errorf("path exists but is not a directory: %s", dir)
 			}
-			g.result.Errors = append(g.result.Errors, fmt.Sprintf("failed to create file %s: %v", fullPath, err))
-			return fmt.Errorf("failed to create file %s: %w", fullPath, err)
+			continue
 		}
-		file.Close()
+		if err != nil {
+			return err
+		}
+		g.report(Event{Action: ActionCreate, Path: dir + "/"})
+		g.recordCreated(dir)
+	}
+	return nil
+}
+
+// setModTime gives a created path the node's modification time, when
+// PreserveModTime is set and the node has one
+func (g *Generator) setModTime(node *parse.Node, fullPath string) error {
+	if !g.options.PreserveModTime || node.ModTime.IsZero() {
+		return nil
+	}
+	if err := os.Chtimes(fullPath, time.Time{}, node.ModTime); err != nil {
+		return g.fail(OpSetModTime, fullPath, err)
+	}
+	return nil
+}
+
+// dirMode returns the permissions for created directories
+func (g *Generator) dirMode() os.FileMode {
+	if g.options.DirMode != 0 {
+		return g.options.DirMode
+	}
+	return fsutil.DirPerm
+}
 
-		g.logger.Verbose("CREATE: %s", fullPath)
-		g.result.Created++
-		g.result.CreatedPaths = append(g.result.CreatedPaths, fullPath)
+// fileMode returns the permissions for created files
+func (g *Generator) fileMode() os.FileMode {
+	if g.options.FileMode != 0 {
+		return g.options.FileMode
 	}
+	return fsutil.FilePerm
+}
 
+// checkSize rejects content over the MaxFileSize limit
+func (g *Generator) checkSize(content string) error {
+	if g.options.MaxFileSize > 0 && int64(len(content)) > g.options.MaxFileSize {
+		return fmt.Errorf("content is %s, over the %s limit",
+			fsutil.FormatSize(int64(len(content))), fsutil.FormatSize(g.options.MaxFileSize))
+	}
 	return nil
 }
 
-// PrintSummary prints a summary of the generation results
+// acquire reserves a slot for a filesystem operation
+func (g *Generator) acquire() {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+}
+
+// release frees a slot reserved by acquire
+func (g *Generator) release() {
+	if g.sem != nil {
+		<-g.sem
+	}
+}
+
+// recordCreated records a created path
+func (g *Generator) recordCreated(path string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.result.Created++
+	g.result.CreatedPaths = append(g.result.CreatedPaths, path)
+}
+
+// recordSkipped records a path that was skipped (it already exists, or --dirs-only left it out)
+func (g *Generator) recordSkipped(path string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.result.Skipped++
+	g.result.SkippedPaths = append(g.result.SkippedPaths, path)
+}
+
+// fail records a failed operation on path and returns it as a *GenerateError
+func (g *Generator) fail(op, path string, err error) error {
+	genErr := &GenerateError{Path: path, Op: op, Err: err}
+
+	g.mu.Lock()
+	g.result.Errors = append(g.result.Errors, genErr.Error())
+	g.result.Failures = append(g.result.Failures, genErr)
+	if g.options.FailFast {
+		g.stopped = true
+	}
+	g.mu.Unlock()
+
+	g.report(Event{Action: ActionFail, Path: path, Reason: op, Err: err})
+	return genErr
+}
+
+// isStopped reports whether a fail-fast build has already failed or the
+// build's context is done
+func (g *Generator) isStopped() bool {
+	if g.ctx.Err() != nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stopped
+}
+
+// ToJSON returns the result as indented JSON
+func (r *Result) ToJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return data, nil
+}
+
+// PrintSummary prints a summary of the generation results to stdout
 func (r *Result) PrintSummary() {
-	fmt.Printf("\nSummary:\n")
-	fmt.Printf("  Created: %d\n", r.Created)
-	fmt.Printf("  Skipped: %d\n", r.Skipped)
+	r.WriteSummary(os.Stdout)
+}
+
+// WriteSummary writes a summary of the generation results to w
+func (r *Result) WriteSummary(w io.Writer) {
+	fmt.Fprintf(w, "\nSummary:\n")
+	fmt.Fprintf(w, "  Created: %d\n", r.Created)
+	fmt.Fprintf(w, "  Skipped: %d\n", r.Skipped)
+	if r.Overwritten > 0 {
+		fmt.Fprintf(w, "  Overwritten: %d\n", r.Overwritten)
+	}
+	if r.RolledBack > 0 {
+		fmt.Fprintf(w, "  Rolled back: %d\n", r.RolledBack)
+	}
 	if len(r.Errors) > 0 {
-		fmt.Printf("  Errors:  %d\n", len(r.Errors))
+		fmt.Fprintf(w, "  Errors:  %d\n", len(r.Errors))
 		for _, err := range r.Errors {
-			fmt.Printf("    - %s\n", err)
+			fmt.Fprintf(w, "    - %s\n", err)
 		}
 	}
 }
@@ -0,0 +1,151 @@
+package generate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// quietLogger discards all output
+var quietLogger = &ConsoleLogger{Quiet: true}
+
+func TestPreserveModTime(t *testing.T) {
+	source := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(source, "src", "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "src", "lib", "util.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "main.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Distinct times, set innermost first so parents keep theirs
+	times := map[string]time.Time{
+		"src/lib/util.go": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		"src/lib":         time.Date(2019, 6, 7, 8, 9, 10, 0, time.UTC),
+		"src":             time.Date(2018, 11, 12, 13, 14, 15, 0, time.UTC),
+		"main.go":         time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC),
+	}
+	for _, rel := range []string{"src/lib/util.go", "src/lib", "src", "main.go"} {
+		if err := os.Chtimes(filepath.Join(source, rel), times[rel], times[rel]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	analysis, err := analyze.NewLocalAnalyzer(source, 5).Analyze(context.Background())
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if len(analysis.Nodes) != 1 {
+		t.Fatalf("Analyze returned %d roots, want 1", len(analysis.Nodes))
+	}
+	nodes := analysis.Nodes[0].Children
+
+	t.Run("preserved", func(t *testing.T) {
+		target := t.TempDir()
+		gen := NewGenerator(Options{TargetDir: target, PreserveModTime: true}, quietLogger)
+		if _, err := gen.Generate(nodes); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+
+		for rel, want := range times {
+			info, err := os.Stat(filepath.Join(target, filepath.FromSlash(rel)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.ModTime().Equal(want) {
+				t.Errorf("%s: mtime %v, want %v", rel, info.ModTime().UTC(), want)
+			}
+		}
+	})
+
+	t.Run("not preserved", func(t *testing.T) {
+		target := t.TempDir()
+		gen := NewGenerator(Options{TargetDir: target}, quietLogger)
+		if _, err := gen.Generate(nodes); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+
+		for rel, old := range times {
+			info, err := os.Stat(filepath.Join(target, filepath.FromSlash(rel)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.ModTime().Equal(old) {
+				t.Errorf("%s: mtime copied from the source without PreserveModTime", rel)
+			}
+		}
+	})
+}
+
+func TestOverwriteKeepsFilesWithoutContent(t *testing.T) {
+	target := t.TempDir()
+	for _, name := range []string{"empty.txt", "filled.txt"} {
+		if err := os.WriteFile(filepath.Join(target, name), []byte("user data\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nodes := []*parse.Node{
+		{Name: "empty.txt"},
+		{Name: "filled.txt", Content: "new\n", HasContent: true},
+	}
+	gen := NewGenerator(Options{
+		TargetDir:   target,
+		Interactive: true,
+		Input:       strings.NewReader("O\n"), // Overwrite all
+	}, quietLogger)
+	result, err := gen.Generate(nodes)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	for name, want := range map[string]string{"empty.txt": "user data\n", "filled.txt": "new\n"} {
+		data, err := os.ReadFile(filepath.Join(target, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if result.Overwritten != 1 || result.Skipped != 1 {
+		t.Errorf("Overwritten = %d, Skipped = %d, want 1 and 1", result.Overwritten, result.Skipped)
+	}
+}
+
+func TestSortedLeavesLayoutOrder(t *testing.T) {
+	src := &parse.Node{Name: "src", IsDir: true, Children: []*parse.Node{{Name: "z.go"}, {Name: "a.go"}}}
+	nodes := []*parse.Node{{Name: "README.md"}, src}
+
+	gen := NewGenerator(Options{TargetDir: t.TempDir(), Sorted: true}, quietLogger)
+	result, err := gen.Generate(nodes)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	// Created in sorted order: directories first, then alphabetical
+	var created []string
+	for _, path := range result.CreatedPaths {
+		rel, _ := filepath.Rel(gen.target, path)
+		created = append(created, filepath.ToSlash(rel))
+	}
+	if want := "src src/a.go src/z.go README.md"; strings.Join(created, " ") != want {
+		t.Errorf("created %v, want %s", created, want)
+	}
+
+	if nodes[0].Name != "README.md" || nodes[1] != src {
+		t.Errorf("top-level nodes reordered to %s, %s", nodes[0].Name, nodes[1].Name)
+	}
+	if src.Children[0].Name != "z.go" || src.Children[1].Name != "a.go" {
+		t.Errorf("src's children reordered to %s, %s", src.Children[0].Name, src.Children[1].Name)
+	}
+}
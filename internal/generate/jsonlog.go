@@ -0,0 +1,89 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// JSONLogger implements EventLogger by writing one JSON object per event
+// (NDJSON). The generator's actions carry their action, path and any reason
+// or error, e.g. {"level":"info","action":"create","path":"/tmp/app/src/"};
+// other messages have only a level and message. Verbose messages are always
+// written, at level "info".
+type JSONLogger struct {
+	Writer io.Writer // Destination for events (defaults to os.Stdout)
+
+	mu sync.Mutex
+}
+
+// jsonEvent is a single log line written by JSONLogger
+type jsonEvent struct {
+	Level   string `json:"level"`
+	Action  string `json:"action,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewJSONLogger creates a JSONLogger writing to w
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{Writer: w}
+}
+
+func (l *JSONLogger) Info(format string, args ...interface{}) {
+	l.write(jsonEvent{Level: "info", Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *JSONLogger) Verbose(format string, args ...interface{}) {
+	l.write(jsonEvent{Level: "info", Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *JSONLogger) Warning(format string, args ...interface{}) {
+	l.write(jsonEvent{Level: "warning", Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *JSONLogger) Error(format string, args ...interface{}) {
+	l.write(jsonEvent{Level: "error", Message: fmt.Sprintf(format, args...)})
+}
+
+// Event implements EventLogger
+func (l *JSONLogger) Event(e Event) {
+	event := jsonEvent{
+		Level:   "info",
+		Action:  e.Action,
+		Path:    e.Path,
+		Reason:  e.Reason,
+		Message: e.String(),
+	}
+	if e.Warning {
+		event.Level = "warning"
+	}
+	if e.Action == ActionFail {
+		event.Level = "error"
+	}
+	if e.Err != nil {
+		event.Error = e.Err.Error()
+	}
+	l.write(event)
+}
+
+// write writes an event as one line
+func (l *JSONLogger) write(event jsonEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	w := l.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+func TestJSONLoggerEvents(t *testing.T) {
+	target := t.TempDir()
+	if err := os.WriteFile(filepath.Join(target, "go.mod"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := []*parse.Node{
+		{Name: "src", IsDir: true, Children: []*parse.Node{{Name: "main.go"}}},
+		{Name: "go.mod"},
+		{Name: "big.txt", Content: "too much content", HasContent: true},
+	}
+
+	var out bytes.Buffer
+	gen := NewGenerator(Options{TargetDir: target, MaxFileSize: 4}, NewJSONLogger(&out))
+	if _, err := gen.Generate(nodes); err == nil {
+		t.Fatal("Generate succeeded despite an oversized file")
+	}
+
+	var events []jsonEvent
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var event jsonEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		if event.Action != "" {
+			events = append(events, event)
+		}
+	}
+
+	want := []jsonEvent{
+		{Level: "info", Action: ActionCreate, Path: filepath.Join(target, "src") + "/"},
+		{Level: "info", Action: ActionCreate, Path: filepath.Join(target, "src", "main.go")},
+		{Level: "warning", Action: ActionSkip, Path: filepath.Join(target, "go.mod"), Reason: "already exists"},
+		{Level: "error", Action: ActionFail, Path: filepath.Join(target, "big.txt"), Reason: OpWriteFile, Error: "content is 16 B, over the 4 B limit"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d action events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, event := range events {
+		event.Message = ""
+		if event != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, event, want[i])
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package generate
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+// DefaultStubs are the content templates used for files without content
+// when stubs are enabled, keyed by extension
+var DefaultStubs = map[string]string{
+	".go": "package {{dir}}\n",
+}
+
+// fileContent returns the content to write for a file node: the layout's
+// content (decoded if binary), else a stub, else none
+func (g *Generator) fileContent(node *parse.Node, fullPath string) (string, bool, error) {
+	if node.HasContent {
+		content, err := parse.DecodeContent(node)
+		return content, true, err
+	}
+	content, ok := g.stubContent(fullPath)
+	return content, ok, nil
+}
+
+// stubContent returns the content for a new file at fullPath from the stub
+// template for its extension, if there is one. Templates may use {{name}}
+// (the file name), {{dir}} (the name of the containing directory) and
+// {{date}} (today, as YYYY-MM-DD).
+func (g *Generator) stubContent(fullPath string) (string, bool) {
+	template, ok := g.options.Stubs[filepath.Ext(fullPath)]
+	if !ok {
+		return "", false
+	}
+
+	replacer := strings.NewReplacer(
+		"{{name}}", filepath.Base(fullPath),
+		"{{dir}}", filepath.Base(filepath.Dir(fullPath)),
+		"{{date}}", time.Now().Format("2006-01-02"),
+	)
+	return replacer.Replace(template), true
+}
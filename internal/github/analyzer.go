@@ -2,94 +2,209 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
-	"time"
 
 	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/httpx"
 	"github.com/pyzamo/chassis/internal/parse"
 )
 
 // GitHubAnalyzer analyzes GitHub repository structures
 type GitHubAnalyzer struct {
+	Progress analyze.ProgressFunc // Optional progress callback, called every ProgressInterval items
+	MaxNodes int                  // Stop after scanning this many items (0 means no limit)
+	Cache    *Cache               // Optional cache of tree responses (nil disables caching)
+	Refresh  bool                 // Ignore cached responses, but still store the fresh one
+	Retry    httpx.RetryPolicy    // Retries for transient API failures
+	Client   *http.Client         // Sends the API requests (see httpx.NewClient)
+	NoFilter bool                 // Keep dependencies, build outputs and dotfiles
+
+	KeepDotfiles   []string // More dotfiles for the filter to keep (see analyze.FilterOptions)
+	KeepLockfiles  bool     // Keep dependency lock files such as go.sum
+	KeepHiddenDirs bool     // Keep dot-directories such as .github
+
+	OnFiltered analyze.FilteredFunc // Optional; told about each item left out and why
+
 	repoURL  string
+	apiURL   string // API base URL, without a trailing slash
+	host     string // Web host that repository URLs use
 	owner    string
 	repo     string
 	maxDepth int
 }
 
-// NewAnalyzer creates a new GitHub analyzer
+// DefaultAPIURL is the base URL of the github.com API
+const DefaultAPIURL = "https://api.github.com"
+
+// DefaultHost is the web host of github.com repositories
+const DefaultHost = "github.com"
+
+// APIURLEnv is the environment variable that sets the API base URL, for
+// GitHub Enterprise (e.g. https://github.mycorp.com/api/v3)
+const APIURLEnv = "GITHUB_API_URL"
+
+// NewAnalyzer creates a new analyzer for a github.com repository
 func NewAnalyzer(repoURL string) *GitHubAnalyzer {
+	return NewAnalyzerWithAPI(repoURL, DefaultAPIURL)
+}
+
+// NewAnalyzerWithAPI creates an analyzer for a repository on the GitHub
+// instance whose API is at apiURL, such as a GitHub Enterprise server. The
+// repository URL is expected on that instance's web host (see WebHost).
+func NewAnalyzerWithAPI(repoURL, apiURL string) *GitHubAnalyzer {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	host := WebHost(apiURL)
+
 	// Parse the URL to extract owner and repo
-	owner, repo := parseGitHubURL(repoURL)
+	owner, repo := parseGitHubURL(repoURL, host)
 
 	return &GitHubAnalyzer{
 		repoURL:  repoURL,
+		apiURL:   apiURL,
+		host:     host,
 		owner:    owner,
 		repo:     repo,
 		maxDepth: 5, // Default max depth
+		Retry:    httpx.DefaultRetryPolicy,
+		Client:   httpx.NewClient(httpx.ClientOptions{}),
 	}
 }
 
-// Analyze fetches and analyzes the GitHub repository structure
-func (a *GitHubAnalyzer) Analyze() (*analyze.Result, error) {
+// Analyze fetches and analyzes the GitHub repository structure, assembling
+// the nodes found by AnalyzeStream into a tree
+func (a *GitHubAnalyzer) Analyze(ctx context.Context) (*analyze.Result, error) {
+	var rootNode *parse.Node
+	result, err := a.AnalyzeStream(ctx, func(node *parse.Node) error {
+		if rootNode == nil {
+			rootNode = node
+			return nil
+		}
+		a.addItemToTree(rootNode, strings.TrimPrefix(node.Path, a.repo+"/"), node.IsDir, node.Size)
+		return nil
+	})
+	if result == nil {
+		return nil, err
+	}
+
+	// An empty (or fully filtered) repository has no tree
+	result.Nodes = []*parse.Node{}
+	if len(rootNode.Children) > 0 {
+		result.Nodes = append(result.Nodes, rootNode)
+	} else {
+		result.DirCount--
+	}
+
+	return result, err
+}
+
+// AnalyzeStream fetches the GitHub repository structure, passing each node
+// to emit in the order GitHub lists them (parents before children).
+// Cancelling ctx aborts the fetch, or stops with the counts so far.
+func (a *GitHubAnalyzer) AnalyzeStream(ctx context.Context, emit analyze.NodeFunc) (*analyze.Result, error) {
 	if a.owner == "" || a.repo == "" {
 		return nil, fmt.Errorf("invalid GitHub URL: %s", a.repoURL)
 	}
 
 	// Fetch repository tree from GitHub API
-	tree, err := a.fetchRepoTree()
+	tree, err := a.fetchRepoTree(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch repository: %w", err)
 	}
 
-	// Convert GitHub tree to our node structure
-	result := &analyze.Result{
-		Nodes: []*parse.Node{},
+	result := &analyze.Result{}
+
+	// The root node, named after the repo, comes first
+	if err := emit(&parse.Node{Name: a.repo, IsDir: true, Path: a.repo}); err != nil {
+		return nil, err
 	}
 
-	// Create root node with repo name
-	rootNode := &parse.Node{
-		Name:     a.repo,
-		IsDir:    true,
-		Path:     a.repo,
-		Children: []*parse.Node{},
+	// GitHub omits entries from recursive listings of very large repositories
+	if tree.Truncated {
+		result.Truncated = true
+		result.TruncatedBy = "GitHub truncated the repository listing"
 	}
 
-	// Build node tree from GitHub response
-	filter := analyze.NewFilter()
+	// Emit a node for each item in the GitHub response
+	filter := analyze.NewFilterWithOptions(analyze.FilterOptions{
+		KeepDotfiles:   a.KeepDotfiles,
+		KeepLockfiles:  a.KeepLockfiles,
+		KeepHiddenDirs: a.KeepHiddenDirs,
+	})
+	if a.NoFilter {
+		filter = nil
+	}
+
+	// Directories already left out, so their contents are skipped quietly
+	skipped := make(map[string]bool)
+
 	for _, item := range tree.Tree {
-		if a.shouldSkipItem(item, filter) {
+		if err := ctx.Err(); err != nil {
+			result.Truncated = true
+			result.TruncatedBy = "cancelled"
+			result.DirCount++ // Count root
+			return result, err
+		}
+
+		if a.MaxNodes > 0 && result.TotalScanned >= a.MaxNodes {
+			// Node limit reached, stop with a partial result
+			result.Truncated = true
+			result.TruncatedBy = fmt.Sprintf("stopped after %d items", a.MaxNodes)
+			break
+		}
+
+		result.TotalScanned++
+		if a.Progress != nil && result.TotalScanned%analyze.ProgressInterval == 0 {
+			a.Progress(result.TotalScanned)
+		}
+
+		if underSkipped(item.Path, skipped) {
+			continue
+		}
+		if skip, reason := a.shouldSkipItem(item, filter); skip {
+			if item.Type == "tree" {
+				skipped[item.Path] = true
+			}
 			result.FilteredCount++
+			if a.OnFiltered != nil {
+				a.OnFiltered(a.repo+"/"+item.Path, reason)
+			}
 			continue
 		}
 
 		// Count the item
-		result.TotalScanned++
 		if item.Type == "tree" {
 			result.DirCount++
 		} else {
 			result.FileCount++
+			result.TotalSize += int64(item.Size)
 		}
 
-		// Add to node tree
-		a.addItemToTree(rootNode, item)
+		node := &parse.Node{
+			Name:  item.Path[strings.LastIndex(item.Path, "/")+1:],
+			IsDir: item.Type == "tree",
+			Path:  a.repo + "/" + item.Path,
+			Size:  int64(item.Size),
+		}
+		if err := emit(node); err != nil {
+			return nil, err
+		}
 	}
 
-	if rootNode.Children != nil && len(rootNode.Children) > 0 {
-		result.Nodes = append(result.Nodes, rootNode)
-		result.DirCount++ // Count root
-	}
+	result.DirCount++ // Count root
 
 	return result, nil
 }
 
 // GitHubTree represents the response from GitHub's tree API
 type GitHubTree struct {
-	Tree []GitHubTreeItem `json:"tree"`
+	Tree      []GitHubTreeItem `json:"tree"`
+	Truncated bool             `json:"truncated"` // True if GitHub omitted entries
 }
 
 // GitHubTreeItem represents a single item in the tree
@@ -100,26 +215,29 @@ type GitHubTreeItem struct {
 }
 
 // fetchRepoTree fetches the repository tree from GitHub API
-func (a *GitHubAnalyzer) fetchRepoTree() (*GitHubTree, error) {
+func (a *GitHubAnalyzer) fetchRepoTree(ctx context.Context) (*GitHubTree, error) {
 	// Use GitHub API to get repository tree
 	// Note: This uses the public API without authentication
 	// Rate limit: 60 requests per hour for unauthenticated requests
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/HEAD?recursive=1", a.owner, a.repo)
+	const ref = "HEAD"
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1", a.apiURL, a.owner, a.repo, ref)
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, err
+	// Serve from the cache when possible
+	if a.Cache != nil && !a.Refresh {
+		if data, ok := a.Cache.Get(a.host, a.owner, a.repo, ref); ok {
+			return decodeTree(data)
+		}
 	}
 
-	// Add headers
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-	req.Header.Set("User-Agent", "chassis-cli")
+	resp, err := a.Retry.Do(a.Client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := client.Do(req)
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -140,20 +258,39 @@ func (a *GitHubAnalyzer) fetchRepoTree() (*GitHubTree, error) {
 		return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+
+	tree, err := decodeTree(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Caching is best-effort; a failure only costs a refetch next time
+	if a.Cache != nil {
+		a.Cache.Put(a.host, a.owner, a.repo, ref, data)
+	}
+
+	return tree, nil
+}
+
+// decodeTree parses a raw tree API response
+func decodeTree(data []byte) (*GitHubTree, error) {
 	var tree GitHubTree
-	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+	if err := json.Unmarshal(data, &tree); err != nil {
 		return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
 	}
-
 	return &tree, nil
 }
 
-// shouldSkipItem checks if an item should be filtered
-func (a *GitHubAnalyzer) shouldSkipItem(item GitHubTreeItem, filter *analyze.Filter) bool {
+// shouldSkipItem checks if an item should be filtered, and why
+func (a *GitHubAnalyzer) shouldSkipItem(item GitHubTreeItem, filter *analyze.Filter) (bool, string) {
 	// Check depth limit
 	depth := strings.Count(item.Path, "/")
 	if depth >= a.maxDepth {
-		return true
+		return true, fmt.Sprintf("deeper than %d levels", a.maxDepth)
 	}
 
 	// Get the last component of the path
@@ -162,50 +299,59 @@ func (a *GitHubAnalyzer) shouldSkipItem(item GitHubTreeItem, filter *analyze.Fil
 
 	// Check filter
 	isDir := item.Type == "tree"
-	return filter.ShouldFilter(name, isDir)
+	return filter.Explain(name, isDir)
 }
 
-// addItemToTree adds a GitHub tree item to our node tree
-func (a *GitHubAnalyzer) addItemToTree(root *parse.Node, item GitHubTreeItem) {
-	parts := strings.Split(item.Path, "/")
-	current := root
-
-	// Navigate/create the path
-	for i, part := range parts {
-		isLastPart := (i == len(parts)-1)
-
-		// Look for existing child
-		var found *parse.Node
-		for _, child := range current.Children {
-			if child.Name == part {
-				found = child
-				break
-			}
+// underSkipped reports whether a path is below one of the skipped directories
+func underSkipped(itemPath string, skipped map[string]bool) bool {
+	for i := strings.LastIndex(itemPath, "/"); i > 0; i = strings.LastIndex(itemPath[:i], "/") {
+		if skipped[itemPath[:i]] {
+			return true
 		}
+	}
+	return false
+}
 
-		if found != nil {
-			current = found
-		} else {
-			// Create new node
-			node := &parse.Node{
-				Name:  part,
-				IsDir: !isLastPart || item.Type == "tree",
-				Path:  item.Path,
-			}
-			current.Children = append(current.Children, node)
-			current = node
-		}
+// addItemToTree adds an item, by its path below the root, to our node tree
+func (a *GitHubAnalyzer) addItemToTree(root *parse.Node, itemPath string, isDir bool, size int64) {
+	// GitHub paths are unique and consistent, so this cannot conflict
+	node, _ := root.AddPath(itemPath, isDir)
+	if node != nil && !isDir {
+		node.Size = size
+	}
+}
+
+// WebHost returns the host that repository URLs use for the GitHub
+// instance whose API is at apiURL: github.com for api.github.com, and the
+// server itself for GitHub Enterprise's https://HOST/api/v3
+func WebHost(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return DefaultHost
 	}
+	return strings.TrimPrefix(strings.ToLower(u.Host), "api.")
+}
+
+// IsRepoURL reports whether source looks like a repository URL on host, as
+// https://HOST/owner/repo, HOST/owner/repo or git@HOST:owner/repo
+func IsRepoURL(source, host string) bool {
+	lower := strings.ToLower(source)
+	return strings.HasPrefix(lower, "https://"+host+"/") ||
+		strings.HasPrefix(lower, "http://"+host+"/") ||
+		strings.HasPrefix(lower, host+"/") ||
+		strings.HasPrefix(lower, "git@"+host+":")
 }
 
 // parseGitHubURL extracts owner and repo from various GitHub URL formats
-func parseGitHubURL(url string) (owner, repo string) {
+// for repositories on host
+func parseGitHubURL(url, host string) (owner, repo string) {
 	// Remove protocol if present
 	url = strings.TrimPrefix(url, "https://")
 	url = strings.TrimPrefix(url, "http://")
 	url = strings.TrimPrefix(url, "git@")
-	url = strings.TrimPrefix(url, "github.com:")
-	url = strings.TrimPrefix(url, "github.com/")
+	if len(url) > len(host) && strings.EqualFold(url[:len(host)], host) {
+		url = strings.TrimLeft(url[len(host):], ":/")
+	}
 
 	// Remove .git suffix if present
 	url = strings.TrimSuffix(url, ".git")
@@ -0,0 +1,62 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+func TestAnalyzeStreamSkipsFilteredDirectoryContents(t *testing.T) {
+	tree := GitHubTree{Tree: []GitHubTreeItem{
+		{Path: "src", Type: "tree"},
+		{Path: "src/index.js", Type: "blob", Size: 10},
+		{Path: "node_modules", Type: "tree"},
+		{Path: "node_modules/x", Type: "tree"},
+		{Path: "node_modules/x/index.js", Type: "blob", Size: 20},
+		{Path: "src/node_modules", Type: "tree"},
+		{Path: "src/node_modules/y.js", Type: "blob", Size: 30},
+		{Path: "package.json", Type: "blob", Size: 40},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(tree)
+	}))
+	defer server.Close()
+
+	analyzer := NewAnalyzerWithAPI(strings.Replace(server.URL, "http://", "https://", 1)+"/owner/app", server.URL)
+	analyzer.Client = server.Client()
+	var filtered []string
+	analyzer.OnFiltered = func(path, reason string) {
+		filtered = append(filtered, path)
+	}
+
+	var emitted []string
+	seen := map[string]bool{"app": true}
+	result, err := analyzer.AnalyzeStream(context.Background(), func(node *parse.Node) error {
+		if parent := node.Path[:max(strings.LastIndex(node.Path, "/"), 0)]; parent != "" && !seen[parent] {
+			t.Errorf("%s emitted before its parent", node.Path)
+		}
+		seen[node.Path] = true
+		emitted = append(emitted, node.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeStream: %v", err)
+	}
+
+	if want := []string{"app", "app/src", "app/src/index.js", "app/package.json"}; !reflect.DeepEqual(emitted, want) {
+		t.Errorf("emitted %v, want %v", emitted, want)
+	}
+	if want := []string{"app/node_modules", "app/src/node_modules"}; !reflect.DeepEqual(filtered, want) {
+		t.Errorf("filtered %v, want %v", filtered, want)
+	}
+	if result.FileCount != 2 || result.DirCount != 2 || result.FilteredCount != 2 || result.TotalSize != 50 {
+		t.Errorf("counts: %d files, %d dirs, %d filtered, %d bytes; want 2, 2, 2, 50",
+			result.FileCount, result.DirCount, result.FilteredCount, result.TotalSize)
+	}
+}
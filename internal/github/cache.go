@@ -0,0 +1,77 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached tree responses stay fresh
+const DefaultCacheTTL = time.Hour
+
+// Cache stores raw GitHub tree responses on disk, keyed by host, owner, repo
+// and ref
+type Cache struct {
+	Dir string        // Directory holding cached responses
+	TTL time.Duration // Maximum age of a usable entry
+}
+
+// DefaultCacheDir returns the cache directory under the user's cache dir
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+	return filepath.Join(dir, "chassis", "github"), nil
+}
+
+// NewCache creates a cache in the default cache directory
+func NewCache(ttl time.Duration) (*Cache, error) {
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir, TTL: ttl}, nil
+}
+
+// path returns the file holding the entry for owner/repo/ref on host.
+// Entries for github.com sit directly in Dir; other hosts get a
+// subdirectory, which can't clash since owner names have no dots.
+func (c *Cache) path(host, owner, repo, ref string) string {
+	// Escape components so URL-derived names can't escape the cache dir
+	dir := c.Dir
+	if host != DefaultHost {
+		dir = filepath.Join(dir, url.PathEscape(host))
+	}
+	return filepath.Join(dir, url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref)+".json")
+}
+
+// Get returns the cached response, if present and younger than the TTL
+func (c *Cache) Get(host, owner, repo, ref string) ([]byte, bool) {
+	path := c.path(host, owner, repo, ref)
+
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores a response in the cache
+func (c *Cache) Put(host, owner, repo, ref string, data []byte) error {
+	path := c.path(host, owner, repo, ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a whole request, including reading the response body
+const DefaultTimeout = 30 * time.Second
+
+// NoTimeout, as ClientOptions.Timeout, leaves a whole request unbounded, for
+// streamed responses and large downloads. Only the wait for the response
+// headers (HeaderTimeout) and the request's context limit it.
+const NoTimeout time.Duration = -1
+
+// HeaderTimeout bounds the wait for a response's headers once a request
+// has been sent, whatever the client's Timeout
+const HeaderTimeout = 30 * time.Second
+
+// DefaultUserAgent identifies chassis to the services it calls
+const DefaultUserAgent = "chassis-cli"
+
+// UserAgentEnv is the environment variable that overrides the User-Agent
+const UserAgentEnv = "CHASSIS_USER_AGENT"
+
+// ProxyEnv is the environment variable that sets a proxy for every request,
+// overriding HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+const ProxyEnv = "CHASSIS_PROXY"
+
+// ClientOptions configures a client made by NewClient
+type ClientOptions struct {
+	Timeout   time.Duration // Limit for each request (0 means DefaultTimeout, NoTimeout or any negative value means none)
+	UserAgent string        // Sent unless a request sets its own (empty means $CHASSIS_USER_AGENT, else DefaultUserAgent)
+	Proxy     *url.URL      // Proxy for every request (nil uses HTTPS_PROXY, HTTP_PROXY and NO_PROXY)
+}
+
+// transports holds one transport per proxy ("" for the environment's), shared
+// by every client so that connections are reused between them
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[string]*http.Transport)
+)
+
+// sharedTransport returns the transport for a proxy, or for the proxy from
+// the environment when proxy is nil
+func sharedTransport(proxy *url.URL) *http.Transport {
+	key := ""
+	if proxy != nil {
+		key = proxy.String()
+	}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if t, ok := transports[key]; ok {
+		return t
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ResponseHeaderTimeout = HeaderTimeout
+	t.Proxy = http.ProxyFromEnvironment
+	if proxy != nil {
+		t.Proxy = http.ProxyURL(proxy)
+	}
+	transports[key] = t
+	return t
+}
+
+// ParseProxy parses a proxy URL such as "http://proxy:3128". A bare
+// "host:port" is taken as an http:// proxy.
+func ParseProxy(s string) (*url.URL, error) {
+	raw := s
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: must be a URL such as http://proxy:3128", s)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be http, https or socks5", s)
+	}
+}
+
+// NewClient returns an HTTP client using the shared transport. Timeouts can
+// still be overridden per client, or per request with a context deadline.
+func NewClient(options ClientOptions) *http.Client {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout < 0 {
+		timeout = 0
+	}
+
+	userAgent := options.UserAgent
+	if userAgent == "" {
+		userAgent = os.Getenv(UserAgentEnv)
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &userAgentTransport{base: sharedTransport(options.Proxy), userAgent: userAgent},
+	}
+}
+
+// userAgentTransport sets the User-Agent of requests that have none
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
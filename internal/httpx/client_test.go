@@ -0,0 +1,145 @@
+package httpx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyzamo/chassis/internal/ai"
+	"github.com/pyzamo/chassis/internal/github"
+	"github.com/pyzamo/chassis/internal/httpx"
+)
+
+// recordingProxy is a proxy that refuses every request and records the
+// hosts it was asked to reach
+type recordingProxy struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (p *recordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.hosts = append(p.hosts, r.Host)
+	p.mu.Unlock()
+	http.Error(w, "refused by test proxy", http.StatusForbidden)
+}
+
+// reached reports whether the proxy was asked to reach host
+func (p *recordingProxy) reached(host string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, h := range p.hosts {
+		if strings.HasPrefix(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// startProxy starts a recording proxy and returns it with its URL
+func startProxy(t *testing.T) (*recordingProxy, *url.URL) {
+	t.Helper()
+	proxy := &recordingProxy{}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+
+	u, err := httpx.ParseProxy(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proxy, u
+}
+
+func TestProxyAppliesToGitHubClient(t *testing.T) {
+	proxy, proxyURL := startProxy(t)
+
+	analyzer := github.NewAnalyzer("https://github.com/pyzamo/chassis")
+	analyzer.Client = httpx.NewClient(httpx.ClientOptions{Proxy: proxyURL})
+	analyzer.Retry.Attempts = 1
+
+	if _, err := analyzer.Analyze(context.Background()); err == nil {
+		t.Fatal("Analyze succeeded through a refusing proxy")
+	}
+	if !proxy.reached("api.github.com") {
+		t.Errorf("proxy was not asked for api.github.com (saw %v)", proxy.hosts)
+	}
+}
+
+func TestProxyAppliesToGeminiClient(t *testing.T) {
+	proxy, proxyURL := startProxy(t)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+
+	client, err := ai.NewGeminiClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Client = httpx.NewClient(httpx.ClientOptions{Proxy: proxyURL})
+	client.Retry.Attempts = 1
+	client.FallbackModels = nil
+
+	if _, err := client.ExtractSkeleton(context.Background(), "src/\n", "Go"); err == nil {
+		t.Fatal("ExtractSkeleton succeeded through a refusing proxy")
+	}
+	if !proxy.reached("generativelanguage.googleapis.com") {
+		t.Errorf("proxy was not asked for generativelanguage.googleapis.com (saw %v)", proxy.hosts)
+	}
+}
+
+func TestNewClientTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{name: "default", timeout: 0, want: httpx.DefaultTimeout},
+		{name: "custom", timeout: 5 * time.Second, want: 5 * time.Second},
+		{name: "none for streaming", timeout: httpx.NoTimeout, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := httpx.NewClient(httpx.ClientOptions{Timeout: tt.timeout})
+			if client.Timeout != tt.want {
+				t.Errorf("Timeout = %v, want %v", client.Timeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoTimeoutOutlastsSlowBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	// A whole-request timeout cuts off the slow body...
+	limited := httpx.NewClient(httpx.ClientOptions{Timeout: 100 * time.Millisecond})
+	if resp, err := limited.Get(server.URL); err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			t.Error("body read within a 100ms timeout, want it cut off")
+		}
+	}
+
+	// ...while NoTimeout reads it in full once the headers have arrived
+	client := httpx.NewClient(httpx.ClientOptions{Timeout: httpx.NoTimeout})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "done" {
+		t.Errorf("body = %q, %v; want \"done\"", body, err)
+	}
+}
@@ -0,0 +1,130 @@
+// Package httpx provides shared HTTP helpers for the API clients
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures retries of transient HTTP failures
+type RetryPolicy struct {
+	Attempts  int           // Total attempts, including the first (1 or less disables retries)
+	BaseDelay time.Duration // Delay before the first retry, doubled for each further retry
+	MaxDelay  time.Duration // Upper bound for any single delay, including Retry-After
+}
+
+// DefaultRetryPolicy is used by the API clients unless configured otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  30 * time.Second,
+}
+
+// Do sends the request returned by newRequest, retrying network errors and
+// retryable status codes (429, 500, 502, 503) with exponential backoff and
+// jitter. newRequest is called for every attempt so request bodies can be
+// re-sent. The final response is returned as-is, whatever its status, for
+// the caller to handle; other statuses such as 401 or 404 are never retried.
+// Waiting between attempts stops early when the request's context is done.
+func (p RetryPolicy) Do(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		last := attempt >= p.Attempts
+
+		if err != nil {
+			if last || !isRetryableError(err) {
+				return nil, err
+			}
+			if err := p.sleep(req.Context(), attempt, 0); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if last || !IsRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		// Discard this response before trying again
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := p.sleep(req.Context(), attempt, retryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// IsRetryableStatus reports whether an HTTP status is worth retrying
+func IsRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryableError reports whether a transport error is worth retrying
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sleep waits before the next attempt, returning the context's error if it
+// is done first. A server-provided Retry-After takes precedence over the
+// computed backoff.
+func (p RetryPolicy) sleep(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	delay := retryAfter
+	if delay <= 0 {
+		delay = p.BaseDelay << (attempt - 1)
+		if delay > 0 {
+			delay += rand.N(delay/2 + 1)
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date, returning 0 if it is missing or invalid
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
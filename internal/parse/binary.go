@@ -0,0 +1,34 @@
+package parse
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Base64Key is the only key of the JSON and YAML objects that give a file
+// binary content, as in {"base64": "iVBORw0KGgo..."}. A directory holding
+// nothing but a file named "base64" with content is read this way too; give
+// that file null instead, or add a sibling.
+const Base64Key = "base64"
+
+// DecodeContent returns a file node's content as the bytes to write,
+// decoding binary content from base64
+func DecodeContent(n *Node) (string, error) {
+	if !n.Binary {
+		return n.Content, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(n.Content)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 content: %w", err)
+	}
+	return string(data), nil
+}
+
+// checkBase64 reports whether data is valid standard base64. Line breaks
+// are allowed, as in wrapped YAML block scalars.
+func checkBase64(data string) error {
+	if _, err := base64.StdEncoding.DecodeString(data); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package parse
+
+import "sort"
+
+// Equal reports whether two trees have the same shape: the same names and
+// file/directory kinds at every level. Children are compared regardless of
+// order, and other fields such as Content or Line are ignored.
+func (n *Node) Equal(other *Node) bool {
+	if n == nil || other == nil {
+		return n == other
+	}
+	return len(DiffNodes([]*Node{n}, []*Node{other})) == 0
+}
+
+// DiffNodes compares two layouts the way Equal does and describes each
+// difference on one line, in path order:
+//
+//	only in first: src/old.go
+//	only in second: docs/
+//	lib: directory in first, file in second
+//
+// Directory paths end with a slash. Returns nil when the layouts match.
+func DiffNodes(a, b []*Node) []string {
+	var diffs []string
+	diffNodes("", a, b, &diffs)
+	return diffs
+}
+
+// diffNodes appends the differences between two sibling lists below prefix
+func diffNodes(prefix string, a, b []*Node, diffs *[]string) {
+	byName := func(nodes []*Node) map[string]*Node {
+		m := make(map[string]*Node, len(nodes))
+		for _, node := range nodes {
+			m[node.Name] = node
+		}
+		return m
+	}
+	inA, inB := byName(a), byName(b)
+
+	names := make([]string, 0, len(inA)+len(inB))
+	for name := range inA {
+		names = append(names, name)
+	}
+	for name := range inB {
+		if inA[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nodeA, nodeB := inA[name], inB[name]
+		switch {
+		case nodeB == nil:
+			*diffs = append(*diffs, "only in first: "+diffPath(prefix, nodeA))
+		case nodeA == nil:
+			*diffs = append(*diffs, "only in second: "+diffPath(prefix, nodeB))
+		case nodeA.IsDir != nodeB.IsDir:
+			*diffs = append(*diffs, prefix+name+": "+kindName(nodeA)+" in first, "+kindName(nodeB)+" in second")
+		case nodeA.IsDir:
+			diffNodes(prefix+name+"/", nodeA.Children, nodeB.Children, diffs)
+		}
+	}
+}
+
+// diffPath returns a node's path for a diff line, with a slash for
+// directories
+func diffPath(prefix string, n *Node) string {
+	if n.IsDir {
+		return prefix + n.Name + "/"
+	}
+	return prefix + n.Name
+}
+
+// kindName names a node's kind for a diff line
+func kindName(n *Node) string {
+	if n.IsDir {
+		return "directory"
+	}
+	return "file"
+}
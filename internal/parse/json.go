@@ -1,20 +1,33 @@
 package parse
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"sort"
 )
 
 // JSONParser parses JSON format layout files
-type JSONParser struct{}
+type JSONParser struct {
+	// SchemaCheck validates the document against LayoutSchema before
+	// converting it, reporting mismatches by JSON pointer
+	SchemaCheck bool
+}
 
 // NewJSONParser creates a new JSON parser
 func NewJSONParser() *JSONParser {
 	return &JSONParser{}
 }
 
+// jsonField is a single key/value pair of a JSON object
+type jsonField struct {
+	Key   string
+	Value interface{}
+}
+
+// jsonObject is a JSON object with its keys in document order
+type jsonObject []jsonField
+
 // Parse implements the Parser interface
 func (p *JSONParser) Parse(reader io.Reader) ([]*Node, error) {
 	// Read the entire input
@@ -23,12 +36,18 @@ func (p *JSONParser) Parse(reader io.Reader) ([]*Node, error) {
 		return nil, fmt.Errorf("reading input: %w", err)
 	}
 
-	// Parse JSON into a generic structure
-	var content interface{}
-	if err := json.Unmarshal(data, &content); err != nil {
+	// Parse JSON into a generic structure, keeping object keys in order
+	content, err := decodeJSON(data)
+	if err != nil {
 		return nil, fmt.Errorf("parsing JSON: %w", err)
 	}
 
+	if p.SchemaCheck {
+		if err := validateSchema(content); err != nil {
+			return nil, fmt.Errorf("schema check: %w", err)
+		}
+	}
+
 	// Handle empty JSON
 	if content == nil {
 		return []*Node{}, nil
@@ -36,30 +55,145 @@ func (p *JSONParser) Parse(reader io.Reader) ([]*Node, error) {
 
 	// Convert to nodes
 	switch v := content.(type) {
-	case map[string]interface{}:
+	case jsonObject:
 		// Root is an object - each key becomes a root node
 		return p.parseObject(v, "")
 	case []interface{}:
-		// Root is an array - not supported for layout
-		return nil, fmt.Errorf("JSON root must be an object, not an array")
+		// Root is an array - a list of slash-separated paths
+		return p.parsePathArray(v)
 	default:
 		return nil, fmt.Errorf("unexpected JSON root type: %T", content)
 	}
 }
 
-// parseObject converts a JSON object to nodes
-func (p *JSONParser) parseObject(obj map[string]interface{}, parentPath string) ([]*Node, error) {
-	var nodes []*Node
+// decodeJSON decodes a complete JSON document, rejecting trailing data
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	content, err := decodeValue(dec)
+	if err == io.EOF {
+		return nil, fmt.Errorf("unexpected end of JSON input")
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	// Sort keys for consistent ordering
-	keys := make([]string, 0, len(obj))
-	for k := range obj {
-		keys = append(keys, k)
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
 	}
-	sort.Strings(keys)
 
-	for _, name := range keys {
-		value := obj[name]
+	return content, nil
+}
+
+// decodeValue reads the next JSON value from the decoder. Objects are
+// decoded as jsonObject so that key order is preserved.
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// Scalar value: string, float64, bool or nil
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := jsonObject{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			// The decoder guarantees object keys are strings
+			obj = append(obj, jsonField{Key: keyTok.(string), Value: value})
+		}
+		// Consume the closing brace
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		// Consume the closing bracket
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// parsePathArray converts a JSON array of path strings to nodes
+func (p *JSONParser) parsePathArray(arr []interface{}) ([]*Node, error) {
+	paths := make([]string, 0, len(arr))
+	for i, value := range arr {
+		path, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("JSON root array must contain path strings, got %T at index %d", value, i)
+		}
+		paths = append(paths, path)
+	}
+	return BuildTreeFromPaths(paths)
+}
+
+// parseArray adds the entries of an array value to its directory node.
+// Strings are files, or paths below the directory such as "lib/util.go"
+// (a trailing slash makes an empty directory); objects add their keys as
+// in any other directory.
+func (p *JSONParser) parseArray(arr []interface{}, dir *Node) error {
+	for i, value := range arr {
+		switch v := value.(type) {
+		case string:
+			if _, err := dir.AddPath(v, false); err != nil {
+				return fmt.Errorf("invalid entry for '%s' at index %d: %w", dir.Path, i, err)
+			}
+		case jsonObject:
+			children, err := p.parseObject(v, dir.Path)
+			if err != nil {
+				return err
+			}
+			dir.Children = append(dir.Children, children...)
+		default:
+			return fmt.Errorf("unexpected array element for '%s' at index %d: %s (use strings for files, objects for directories)", dir.Path, i, jsonType(value))
+		}
+	}
+	return nil
+}
+
+// base64Content returns the data of a {"base64": "..."} object
+func base64Content(obj jsonObject) (string, bool) {
+	if len(obj) != 1 || obj[0].Key != Base64Key {
+		return "", false
+	}
+	data, ok := obj[0].Value.(string)
+	return data, ok
+}
+
+// parseObject converts a JSON object to nodes
+func (p *JSONParser) parseObject(obj jsonObject, parentPath string) ([]*Node, error) {
+	var nodes []*Node
+
+	// Keys are processed in document order
+	for _, field := range obj {
+		name := field.Key
+		value := field.Value
 		node := &Node{
 			Name: name,
 			Path: name,
@@ -71,8 +205,19 @@ func (p *JSONParser) parseObject(obj map[string]interface{}, parentPath string)
 
 		// Determine if it's a directory or file based on value
 		switch v := value.(type) {
-		case map[string]interface{}:
-			// Object means directory
+		case jsonObject:
+			// {"base64": "..."} is a file with binary content
+			if data, ok := base64Content(v); ok {
+				if err := checkBase64(data); err != nil {
+					return nil, fmt.Errorf("invalid base64 content for '%s': %w", node.Path, err)
+				}
+				node.Content = data
+				node.HasContent = true
+				node.Binary = true
+				break
+			}
+
+			// Any other object means directory
 			node.IsDir = true
 
 			// Check if it's an empty object
@@ -93,16 +238,24 @@ func (p *JSONParser) parseObject(obj map[string]interface{}, parentPath string)
 			node.IsDir = false
 
 		case string:
-			// Empty string also means file
-			if v == "" {
-				node.IsDir = false
-			} else {
-				return nil, fmt.Errorf("unexpected string value for '%s': files should have null or empty string value, got %q", name, v)
+			// Empty string also means file, any other string is its content
+			node.IsDir = false
+			if v != "" {
+				node.Content = v
+				node.HasContent = true
+			}
+
+		case []interface{}:
+			// An array is a directory listing its files and subdirectories
+			node.IsDir = true
+			node.Children = []*Node{}
+			if err := p.parseArray(v, node); err != nil {
+				return nil, err
 			}
 
-		case float64, bool, []interface{}:
+		case float64, bool:
 			// These types are not valid for our layout
-			return nil, fmt.Errorf("unexpected value type for '%s': %T (use null for files, {} for directories)", name, value)
+			return nil, fmt.Errorf("unexpected value type for '%s': %T (use null or a string for files, {} or [] for directories)", name, value)
 
 		default:
 			return nil, fmt.Errorf("unexpected value type for '%s': %T", name, value)
@@ -0,0 +1,73 @@
+package parse
+
+import "fmt"
+
+// MergeNodes merges two layouts into one. Directories with the same name are
+// combined, their children merged recursively; a file may appear in both
+// only if the two entries are identical. A name that is a file in one layout
+// and a directory in the other is an error. The result keeps a's order, with
+// entries only in b appended; neither input is modified. Names repeated
+// within one layout are not merged but kept, so validation reports them.
+func MergeNodes(a, b []*Node) ([]*Node, error) {
+	return mergeNodes(a, b, "")
+}
+
+// mergeNodes merges two sibling lists under parentPath (for error messages)
+func mergeNodes(a, b []*Node, parentPath string) ([]*Node, error) {
+	merged := make([]*Node, 0, len(a)+len(b))
+	index := make(map[string]int, len(a))
+	for _, node := range a {
+		index[node.Name] = len(merged)
+		merged = append(merged, node)
+	}
+
+	// Only a's entries are matched, and each only once, so that a name
+	// repeated within b is kept twice for the validator to reject
+	matched := make(map[string]bool, len(b))
+	for _, node := range b {
+		i, ok := index[node.Name]
+		if !ok || matched[node.Name] {
+			merged = append(merged, node)
+			continue
+		}
+		matched[node.Name] = true
+
+		existing := merged[i]
+		path := node.Name
+		if parentPath != "" {
+			path = parentPath + "/" + node.Name
+		}
+
+		switch {
+		case existing.IsDir != node.IsDir:
+			return nil, fmt.Errorf("cannot merge %s: it is a %s in one layout and a %s in the other",
+				path, kind(existing), kind(node))
+		case existing.IsDir:
+			children, err := mergeNodes(existing.Children, node.Children, path)
+			if err != nil {
+				return nil, err
+			}
+			combined := *existing
+			combined.Children = children
+			merged[i] = &combined
+		case !sameFile(existing, node):
+			return nil, fmt.Errorf("cannot merge %s: the file is defined differently in each layout", path)
+		}
+	}
+
+	return merged, nil
+}
+
+// sameFile reports whether two file nodes would create the same file
+func sameFile(a, b *Node) bool {
+	return a.HasContent == b.HasContent && a.Content == b.Content && a.Binary == b.Binary &&
+		a.Executable == b.Executable
+}
+
+// kind names a node's type for error messages
+func kind(n *Node) string {
+	if n.IsDir {
+		return "directory"
+	}
+	return "file"
+}
@@ -0,0 +1,193 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dir and file build layout nodes for tests
+func dir(name string, children ...*Node) *Node {
+	return &Node{Name: name, IsDir: true, Children: children}
+}
+
+func file(name string) *Node {
+	return &Node{Name: name}
+}
+
+func fileWithContent(name, content string) *Node {
+	return &Node{Name: name, Content: content, HasContent: true}
+}
+
+// listPaths lists every path of a layout in order, directories with a
+// trailing slash
+func listPaths(nodes []*Node) []string {
+	var paths []string
+	var walk func(prefix string, nodes []*Node)
+	walk = func(prefix string, nodes []*Node) {
+		for _, node := range nodes {
+			if node.IsDir {
+				paths = append(paths, prefix+node.Name+"/")
+				walk(prefix+node.Name+"/", node.Children)
+			} else {
+				paths = append(paths, prefix+node.Name)
+			}
+		}
+	}
+	walk("", nodes)
+	return paths
+}
+
+func TestMergeNodes(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []*Node
+		want []string
+	}{
+		{
+			name: "disjoint trees",
+			a:    []*Node{dir("src", file("main.go")), file("go.mod")},
+			b:    []*Node{dir("docs", file("index.md")), file("README.md")},
+			want: []string{"src/", "src/main.go", "go.mod", "docs/", "docs/index.md", "README.md"},
+		},
+		{
+			name: "same-named directories",
+			a:    []*Node{dir("src", file("main.go"), dir("lib", file("a.go")))},
+			b:    []*Node{dir("src", dir("lib", file("b.go")), file("util.go"))},
+			want: []string{"src/", "src/main.go", "src/lib/", "src/lib/a.go", "src/lib/b.go", "src/util.go"},
+		},
+		{
+			name: "identical files",
+			a:    []*Node{file("go.mod"), fileWithContent("LICENSE", "MIT\n")},
+			b:    []*Node{fileWithContent("LICENSE", "MIT\n"), file("go.mod")},
+			want: []string{"go.mod", "LICENSE"},
+		},
+		{
+			name: "empty first layout",
+			a:    nil,
+			b:    []*Node{dir("src")},
+			want: []string{"src/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeNodes(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("MergeNodes: %v", err)
+			}
+			if got := listPaths(merged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeNodesConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []*Node
+		wantErr string
+	}{
+		{
+			name:    "file and directory",
+			a:       []*Node{dir("src", file("lib"))},
+			b:       []*Node{dir("src", dir("lib"))},
+			wantErr: "cannot merge src/lib: it is a file in one layout and a directory in the other",
+		},
+		{
+			name:    "different content",
+			a:       []*Node{fileWithContent("README.md", "# one\n")},
+			b:       []*Node{fileWithContent("README.md", "# two\n")},
+			wantErr: "cannot merge README.md: the file is defined differently",
+		},
+		{
+			name:    "content and no content",
+			a:       []*Node{file("README.md")},
+			b:       []*Node{fileWithContent("README.md", "")},
+			wantErr: "cannot merge README.md",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MergeNodes(tt.a, tt.b)
+			if err == nil {
+				t.Fatal("MergeNodes succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeNodesDoesNotModifyInputs(t *testing.T) {
+	lib := dir("lib", file("a.go"))
+	src := dir("src", file("main.go"), lib)
+	a := []*Node{src}
+	b := []*Node{dir("src", dir("lib", file("b.go")), file("util.go")), file("go.mod")}
+
+	wantA, wantB := listPaths(a), listPaths(b)
+	srcChildren := src.Children
+	libChildren := lib.Children
+
+	if _, err := MergeNodes(a, b); err != nil {
+		t.Fatalf("MergeNodes: %v", err)
+	}
+
+	if got := listPaths(a); !reflect.DeepEqual(got, wantA) {
+		t.Errorf("first layout changed to %v, want %v", got, wantA)
+	}
+	if got := listPaths(b); !reflect.DeepEqual(got, wantB) {
+		t.Errorf("second layout changed to %v, want %v", got, wantB)
+	}
+	if a[0] != src || src.Children[1] != lib {
+		t.Error("nodes of the first layout were replaced")
+	}
+	if len(src.Children) != len(srcChildren) || &src.Children[0] != &srcChildren[0] {
+		t.Error("src's children were replaced")
+	}
+	if len(lib.Children) != len(libChildren) || &lib.Children[0] != &libChildren[0] {
+		t.Error("lib's children were replaced")
+	}
+}
+
+func TestMergeNodesKeepsDuplicatesWithinLayout(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []*Node
+		want []string
+	}{
+		{
+			name: "single layout",
+			a:    nil,
+			b:    []*Node{dir("src", file("a.go")), file("README.md"), dir("src", file("b.go")), file("README.md")},
+			want: []string{"src/", "src/a.go", "README.md", "src/", "src/b.go", "README.md"},
+		},
+		{
+			name: "file and directory in one layout",
+			a:    nil,
+			b:    []*Node{file("docs"), dir("docs")},
+			want: []string{"docs", "docs/"},
+		},
+		{
+			name: "repeated in the second layout",
+			a:    []*Node{dir("src", file("a.go"))},
+			b:    []*Node{dir("src", file("b.go")), dir("src", file("c.go"))},
+			want: []string{"src/", "src/a.go", "src/b.go", "src/", "src/c.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeNodes(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("MergeNodes: %v", err)
+			}
+			if got := listPaths(merged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,19 +2,31 @@
 package parse
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Node represents a single entry in the directory tree
 type Node struct {
-	Name     string  // Name of the file or directory
-	IsDir    bool    // True if this is a directory
-	Children []*Node // Child nodes (only for directories)
-	Path     string  // Full path from root (for error reporting)
-	Line     int     // Line number in source file (for error reporting)
+	Name       string    // Name of the file or directory
+	IsDir      bool      // True if this is a directory
+	Children   []*Node   // Child nodes (only for directories)
+	Path       string    // Full path from root (for error reporting)
+	Line       int       // Line number in source file (for error reporting)
+	Content    string    // Inline file content (only for files)
+	HasContent bool      // True if the layout specified content for this file
+	Binary     bool      // True if Content is base64-encoded binary data
+	Executable bool      // True if the file should be created executable
+	Size       int64     // Size in bytes of an analyzed file (0 when unknown)
+	ModTime    time.Time // Modification time of an analyzed entry (zero when unknown)
+	Comment    string    // Lines written above the node, each ending in "\n": comments including the '#', or blank
+	Include    string    // Path of an @include line left unresolved (see PlainTextParser.KeepIncludes); the node has no name
 }
 
 // Parser is the interface that all format parsers must implement
@@ -30,6 +42,7 @@ const (
 	FormatPlainText
 	FormatYAML
 	FormatJSON
+	FormatPaths // Newline-separated path list, only selectable explicitly
 )
 
 // String returns the string representation of the format
@@ -41,6 +54,8 @@ func (f Format) String() string {
 		return "YAML"
 	case FormatJSON:
 		return "JSON"
+	case FormatPaths:
+		return "path-list"
 	default:
 		return "unknown"
 	}
@@ -66,6 +81,55 @@ func DetectFormat(filename string) Format {
 	}
 }
 
+// FormatFromName maps a user-facing format name (as accepted by --format) to a Format
+func FormatFromName(name string) Format {
+	switch strings.ToLower(name) {
+	case "tree", "txt", "text", "plain-text":
+		return FormatPlainText
+	case "yaml", "yml":
+		return FormatYAML
+	case "json":
+		return FormatJSON
+	case "paths", "path-list":
+		return FormatPaths
+	default:
+		return FormatUnknown
+	}
+}
+
+// sniffSize is the number of bytes inspected by DetectFormatFromContent
+const sniffSize = 4096
+
+// DetectFormatFromContent determines the format by peeking at the content.
+// A leading '{' or '[' implies JSON, a leading "key:" line implies YAML,
+// and anything else is treated as plain-text. The returned reader yields
+// the full content, including the bytes that were inspected.
+func DetectFormatFromContent(reader io.Reader) (Format, io.Reader) {
+	buffered := bufio.NewReaderSize(reader, sniffSize)
+	peeked, _ := buffered.Peek(sniffSize)
+
+	for _, line := range bytes.Split(peeked, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+
+		// Skip blank lines and comments, which all formats but JSON allow
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			continue
+		}
+
+		if trimmed[0] == '{' || trimmed[0] == '[' {
+			return FormatJSON, buffered
+		}
+
+		if bytes.HasSuffix(trimmed, []byte(":")) || bytes.Contains(trimmed, []byte(": ")) {
+			return FormatYAML, buffered
+		}
+
+		return FormatPlainText, buffered
+	}
+
+	return FormatPlainText, buffered
+}
+
 // Parse reads from the reader and returns the parsed tree structure
 func Parse(reader io.Reader, format Format) ([]*Node, error) {
 	var parser Parser
@@ -77,6 +141,8 @@ func Parse(reader io.Reader, format Format) ([]*Node, error) {
 		parser = NewYAMLParser()
 	case FormatJSON:
 		parser = NewJSONParser()
+	case FormatPaths:
+		parser = NewPathListParser()
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
@@ -84,17 +150,39 @@ func Parse(reader io.Reader, format Format) ([]*Node, error) {
 	return parser.Parse(reader)
 }
 
+// Options configures parsing
+type Options struct {
+	IndentWidth int    // Plain-text indent width (0 or less auto-detects)
+	TabWidth    int    // Plain-text tab stop width for mixed tabs and spaces (0 rejects mixing)
+	Strict      bool   // Plain-text strict mode (see PlainTextParser.Strict)
+	File        string // Path of the layout, for resolving plain-text @include lines
+	SchemaCheck bool   // Validate JSON layouts against LayoutSchema first
+}
+
 // ParseWithIndent reads from the reader with a specific indent width for plain-text
 func ParseWithIndent(reader io.Reader, format Format, indentWidth int) ([]*Node, error) {
+	return ParseWithOptions(reader, format, Options{IndentWidth: indentWidth})
+}
+
+// ParseWithOptions reads from the reader using the given parser options
+func ParseWithOptions(reader io.Reader, format Format, options Options) ([]*Node, error) {
 	var parser Parser
 
 	switch format {
 	case FormatPlainText:
-		parser = NewPlainTextParser(indentWidth)
+		plain := NewPlainTextParser(options.IndentWidth)
+		plain.TabWidth = options.TabWidth
+		plain.Strict = options.Strict
+		plain.File = options.File
+		parser = plain
 	case FormatYAML:
 		parser = NewYAMLParser()
 	case FormatJSON:
-		parser = NewJSONParser()
+		jsonParser := NewJSONParser()
+		jsonParser.SchemaCheck = options.SchemaCheck
+		parser = jsonParser
+	case FormatPaths:
+		parser = NewPathListParser()
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
@@ -146,3 +234,116 @@ func (n *Node) CountNodes() int {
 	}
 	return count
 }
+
+// MaxDepth returns the number of levels in the tree, counting the node
+// itself as level 1
+func (n *Node) MaxDepth() int {
+	deepest := 0
+	for _, child := range n.Children {
+		if d := child.MaxDepth(); d > deepest {
+			deepest = d
+		}
+	}
+	return deepest + 1
+}
+
+// Prune removes descendant directories that contain no files, either
+// directly or in any subdirectory. Children are pruned bottom-up, so a
+// directory holding only empty directories is removed too. It reports
+// whether the node itself contains any files (files always report true).
+func (n *Node) Prune() bool {
+	if !n.IsDir {
+		return true
+	}
+
+	kept := n.Children[:0]
+	for _, child := range n.Children {
+		if child.Prune() {
+			kept = append(kept, child)
+		}
+	}
+	n.Children = kept
+
+	return len(kept) > 0
+}
+
+// Truncate removes everything deeper than depth levels, counting the node
+// itself as level 1. Directories at the limit are kept, without children.
+// A depth below 1 leaves the tree unchanged.
+func (n *Node) Truncate(depth int) {
+	if depth < 1 {
+		return
+	}
+	if depth == 1 {
+		n.Children = nil
+		return
+	}
+	for _, child := range n.Children {
+		child.Truncate(depth - 1)
+	}
+}
+
+// AddPath adds a slash-separated path below the node, creating any missing
+// intermediate directories, and returns the node for the final component.
+// isDir marks the final component as a directory; a trailing slash does too.
+// Existing nodes are reused, so adding the same path twice is harmless.
+func (n *Node) AddPath(p string, isDir bool) (*Node, error) {
+	if strings.HasSuffix(p, "/") {
+		isDir = true
+		p = strings.TrimSuffix(p, "/")
+	}
+
+	parts := strings.Split(p, "/")
+	current := n
+
+	for i, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid path %q: empty path segment", p)
+		}
+
+		partIsDir := isDir || i < len(parts)-1
+		child := current.FindChild(part)
+		if child == nil {
+			child = current.AddChild(part, partIsDir)
+		} else if child.IsDir != partIsDir {
+			return nil, fmt.Errorf("invalid path %q: '%s' is used as both a file and a directory", p, child.Path)
+		}
+		current = child
+	}
+
+	return current, nil
+}
+
+// BuildTreeFromPaths builds a node tree from a list of slash-separated paths.
+// Intermediate components become directories, and a trailing slash marks
+// the final component as a directory.
+func BuildTreeFromPaths(paths []string) ([]*Node, error) {
+	root := &Node{IsDir: true}
+	for _, p := range paths {
+		if _, err := root.AddPath(p, false); err != nil {
+			return nil, err
+		}
+	}
+	return root.Children, nil
+}
+
+// Sort recursively sorts the node's children, directories first and then
+// alphabetically (case-insensitive)
+func (n *Node) Sort() {
+	SortNodes(n.Children)
+	for _, child := range n.Children {
+		child.Sort()
+	}
+}
+
+// SortNodes sorts nodes alphabetically (directories first, then files)
+func SortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		// If one is dir and other is file, dir comes first
+		if nodes[i].IsDir != nodes[j].IsDir {
+			return nodes[i].IsDir
+		}
+		// Otherwise alphabetical
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+}
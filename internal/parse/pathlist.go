@@ -0,0 +1,54 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PathListParser parses a newline-separated list of paths, such as the
+// output of `git ls-files`
+type PathListParser struct{}
+
+// NewPathListParser creates a new path-list parser
+func NewPathListParser() *PathListParser {
+	return &PathListParser{}
+}
+
+// Parse implements the Parser interface
+func (p *PathListParser) Parse(reader io.Reader) ([]*Node, error) {
+	scanner := bufio.NewScanner(reader)
+	root := &Node{IsDir: true}
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		path := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines
+		if path == "" {
+			continue
+		}
+
+		path = strings.TrimPrefix(path, "./")
+
+		node, err := root.AddPath(path, false)
+		if err != nil {
+			return nil, NewParseError(lineNum, err.Error())
+		}
+		if node.Line == 0 {
+			node.Line = lineNum
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %w", err)
+	}
+
+	if root.Children == nil {
+		return []*Node{}, nil
+	}
+
+	return root.Children, nil
+}
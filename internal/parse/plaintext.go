@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -11,6 +13,28 @@ import (
 type PlainTextParser struct {
 	IndentWidth int  // Expected width of indentation (default 2)
 	AutoDetect  bool // Auto-detect indent width from first indented line
+
+	// TabWidth expands leading tabs to tab stops every TabWidth columns, so
+	// lines may mix tabs and spaces. When 0, mixing them is an error.
+	TabWidth int
+
+	// Strict rejects what is otherwise tolerated: trailing whitespace, tabs
+	// within a name, and names with a trailing dot or a leading "..".
+	Strict bool
+
+	// File is the path of the layout being parsed. "@include path" lines
+	// are resolved relative to it, and are an error when it is empty.
+	File string
+
+	// KeepIncludes leaves "@include path" lines unresolved, as nodes with
+	// only Include set, so a layout can be rewritten without inlining them
+	KeepIncludes bool
+
+	// TrailingComment is set by Parse to the comment and blank lines after
+	// the last entry, in the form of Node.Comment
+	TrailingComment string
+
+	includeChain []string // Files including this one, outermost first
 }
 
 // NewPlainTextParser creates a new plain-text parser
@@ -25,15 +49,22 @@ func NewPlainTextParser(indentWidth int) *PlainTextParser {
 func (p *PlainTextParser) Parse(reader io.Reader) ([]*Node, error) {
 	scanner := bufio.NewScanner(reader)
 	var lines []parsedLine
+	var comments []string
 	lineNum := 0
 
 	// First pass: read and parse all lines
 	for scanner.Scan() {
 		lineNum++
-		text := scanner.Text()
+		// Drop the carriage return of CRLF line endings
+		text := strings.TrimSuffix(scanner.Text(), "\r")
 
-		// Skip empty lines
+		// Keep one blank line between entries, recorded with the comments
+		// of the next node; leading and repeated blank lines are dropped
 		if len(strings.TrimSpace(text)) == 0 {
+			if (len(lines) > 0 || len(comments) > 0) &&
+				(len(comments) == 0 || comments[len(comments)-1] != "") {
+				comments = append(comments, "")
+			}
 			continue
 		}
 
@@ -43,11 +74,20 @@ func (p *PlainTextParser) Parse(reader io.Reader) ([]*Node, error) {
 			return nil, err
 		}
 
-		// Skip comments
+		// Hold comments for the node that follows them
 		if parsed.isComment {
+			comments = append(comments, parsed.content)
 			continue
 		}
 
+		// An inline comment follows any comment lines above the node
+		if parsed.comment != "" {
+			comments = append(comments, parsed.comment)
+		}
+		if len(comments) > 0 {
+			parsed.comment = strings.Join(comments, "\n") + "\n"
+		}
+		comments = nil
 		lines = append(lines, parsed)
 	}
 
@@ -55,6 +95,16 @@ func (p *PlainTextParser) Parse(reader io.Reader) ([]*Node, error) {
 		return nil, fmt.Errorf("error reading input: %w", err)
 	}
 
+	// Comments after the last entry belong to no node; blank lines at the
+	// end are dropped
+	for len(comments) > 0 && comments[len(comments)-1] == "" {
+		comments = comments[:len(comments)-1]
+	}
+	p.TrailingComment = ""
+	if len(comments) > 0 {
+		p.TrailingComment = strings.Join(comments, "\n") + "\n"
+	}
+
 	if len(lines) == 0 {
 		return []*Node{}, nil
 	}
@@ -75,7 +125,10 @@ type parsedLine struct {
 	indent     int    // Number of leading spaces/tabs
 	indentChar rune   // ' ' or '\t'
 	isDir      bool   // True if ends with /
+	isExec     bool   // True if a file name ends with the * executable marker
 	isComment  bool   // True if line is a comment
+	include    string // Path of an "@include path" directive
+	comment    string // Comment and blank lines preceding this one, then any inline comment
 	lineNum    int    // Line number in source
 }
 
@@ -86,34 +139,66 @@ func (p *PlainTextParser) parseLine(text string, lineNum int) (parsedLine, error
 		lineNum: lineNum,
 	}
 
-	// Count leading whitespace
-	for i, ch := range text {
-		if ch == ' ' || ch == '\t' {
-			if line.indentChar == 0 {
-				line.indentChar = ch
-			} else if line.indentChar != ch {
-				return line, NewIndentationError(lineNum, i+1,
-					int(line.indentChar), int(ch))
+	// Count leading whitespace, in columns when expanding tabs
+	if p.TabWidth > 0 {
+		line.indent, line.indentChar = expandIndent(text, p.TabWidth)
+	} else {
+		for i, ch := range text {
+			if ch == ' ' || ch == '\t' {
+				if line.indentChar == 0 {
+					line.indentChar = ch
+				} else if line.indentChar != ch {
+					return line, NewIndentationError(lineNum, i+1,
+						int(line.indentChar), int(ch))
+				}
+				line.indent++
+			} else {
+				break
 			}
-			line.indent++
-		} else {
-			break
 		}
 	}
 
 	// Get the content after indentation
 	line.content = strings.TrimSpace(text)
 
+	if p.Strict {
+		if trimmed := strings.TrimRight(text, " \t"); trimmed != text {
+			return line, &ParseError{Line: lineNum, Column: len(trimmed) + 1, Message: "trailing whitespace (strict mode)"}
+		}
+	}
+
 	// Check for comments
 	if strings.HasPrefix(line.content, "#") {
 		line.isComment = true
 		return line, nil
 	}
 
-	// Check if it's a directory
+	// Strip an inline comment; the '#' must follow whitespace so that names
+	// like C#Project.cs are left alone
+	if i := inlineCommentIndex(line.content); i >= 0 {
+		line.comment = "# " + strings.TrimSpace(line.content[i+1:])
+		line.content = strings.TrimSpace(line.content[:i])
+	}
+
+	// An include directive splices another layout in at this level
+	if rest, ok := strings.CutPrefix(line.content, "@include"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+		line.include = strings.TrimSpace(rest)
+		if line.include == "" {
+			return line, NewParseError(lineNum, "@include needs a file path")
+		}
+		return line, nil
+	}
+
+	// Check if it's a directory, accepting repeated slashes such as "src//"
 	if strings.HasSuffix(line.content, "/") {
 		line.isDir = true
-		line.content = strings.TrimSuffix(line.content, "/")
+		line.content = strings.TrimRight(line.content, "/")
+	}
+
+	// Check if it's an executable file (trailing *, as in `ls -F`)
+	if !line.isDir && strings.HasSuffix(line.content, "*") {
+		line.isExec = true
+		line.content = strings.TrimSuffix(line.content, "*")
 	}
 
 	// Validate the name
@@ -121,6 +206,12 @@ func (p *PlainTextParser) parseLine(text string, lineNum int) (parsedLine, error
 		return line, NewParseError(lineNum, "empty name after trimming")
 	}
 
+	if p.Strict {
+		if err := checkStrictName(text, line.content, lineNum); err != nil {
+			return line, err
+		}
+	}
+
 	// Check for multiple slashes or invalid patterns
 	if strings.Contains(line.content, "/") {
 		return line, NewParseError(lineNum,
@@ -131,22 +222,80 @@ func (p *PlainTextParser) parseLine(text string, lineNum int) (parsedLine, error
 	return line, nil
 }
 
-// detectIndentation auto-detects the indentation width
+// checkStrictName rejects names that strict mode doesn't allow. Dotfiles
+// such as .gitignore are fine; a trailing dot is dropped by Windows and a
+// leading ".." is easily mistaken for a parent reference.
+func checkStrictName(text, name string, lineNum int) error {
+	if i := strings.IndexByte(name, '\t'); i >= 0 {
+		return &ParseError{
+			Line:    lineNum,
+			Column:  strings.Index(text, name) + i + 1,
+			Message: fmt.Sprintf("tab character in name '%s' (strict mode)", name),
+		}
+	}
+	if strings.HasSuffix(name, ".") {
+		return NewParseError(lineNum, fmt.Sprintf("name '%s' ends with a dot (strict mode)", name))
+	}
+	if strings.HasPrefix(name, "..") {
+		return NewParseError(lineNum, fmt.Sprintf("name '%s' starts with '..' (strict mode)", name))
+	}
+	return nil
+}
+
+// expandIndent measures the leading whitespace of text in columns, with tab
+// stops every tabWidth columns
+func expandIndent(text string, tabWidth int) (int, rune) {
+	col := 0
+	for _, ch := range text {
+		switch ch {
+		case ' ':
+			col++
+		case '\t':
+			col += tabWidth - col%tabWidth
+		default:
+			return col, ' '
+		}
+	}
+	return col, ' '
+}
+
+// inlineCommentIndex returns the index of the '#' starting an inline
+// comment in content, or -1 if there is none
+func inlineCommentIndex(content string) int {
+	for i := 1; i < len(content); i++ {
+		if content[i] == '#' && (content[i-1] == ' ' || content[i-1] == '\t') {
+			return i
+		}
+	}
+	return -1
+}
+
+// detectIndentation auto-detects the indentation width as the greatest
+// common divisor of every indent increase, so one irregular nesting step
+// doesn't decide the width for the whole document
 func (p *PlainTextParser) detectIndentation(lines []parsedLine) {
-	// Find the first indented line to detect indent width
+	width := 0
 	for i := 1; i < len(lines); i++ {
-		if lines[i].indent > lines[i-1].indent {
-			p.IndentWidth = lines[i].indent - lines[i-1].indent
-			break
+		if delta := lines[i].indent - lines[i-1].indent; delta > 0 {
+			width = gcd(width, delta)
 		}
 	}
+	p.IndentWidth = width
 
-	// Default to 2 if we couldn't detect
+	// Default to 2 if there are no indented lines
 	if p.IndentWidth <= 0 {
 		p.IndentWidth = 2
 	}
 }
 
+// gcd returns the greatest common divisor of a and b
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
 // buildTree builds the tree structure from parsed lines
 func (p *PlainTextParser) buildTree(lines []parsedLine) ([]*Node, error) {
 	if len(lines) == 0 {
@@ -176,9 +325,11 @@ func (p *PlainTextParser) buildTree(lines []parsedLine) ([]*Node, error) {
 
 		// Create new node
 		node := &Node{
-			Name:  line.content,
-			IsDir: line.isDir,
-			Line:  line.lineNum,
+			Name:       line.content,
+			IsDir:      line.isDir,
+			Line:       line.lineNum,
+			Executable: line.isExec,
+			Comment:    line.comment,
 		}
 
 		// Pop stack to correct depth
@@ -188,9 +339,26 @@ func (p *PlainTextParser) buildTree(lines []parsedLine) ([]*Node, error) {
 
 		// Validate depth increase
 		if depth > len(stack) {
+			parent := stack[len(stack)-1].node
+			parentName := parent.Name
+			if parent.IsDir {
+				parentName += "/"
+			}
+			unit := "spaces"
+			if line.indentChar == '\t' {
+				unit = "tabs"
+			}
 			return nil, NewParseError(line.lineNum,
-				fmt.Sprintf("invalid indentation: jumped from level %d to %d (can only increase by 1)",
-					len(stack), depth))
+				fmt.Sprintf("invalid indentation for '%s': expected %d %s, got %d (one level below '%s'; nesting can only increase by 1 level)",
+					strings.TrimSpace(line.text), len(stack)*p.IndentWidth, unit, line.indent, parentName))
+		}
+
+		// Splice in included layouts instead of adding a node
+		if line.include != "" {
+			if err := p.spliceInclude(line, depth, &roots, &stack); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
 		// Add node to tree
@@ -200,6 +368,11 @@ func (p *PlainTextParser) buildTree(lines []parsedLine) ([]*Node, error) {
 			stack = []*stackItem{{node: node, depth: 0}}
 		} else {
 			// Child node
+			if stack[len(stack)-1].include {
+				return nil, NewParseError(line.lineNum,
+					fmt.Sprintf("'%s' is indented under an @include line, which cannot have children",
+						line.content))
+			}
 			parent := stack[len(stack)-1].node
 			if !parent.IsDir {
 				return nil, NewParseError(line.lineNum,
@@ -225,8 +398,107 @@ func (p *PlainTextParser) buildTree(lines []parsedLine) ([]*Node, error) {
 
 // stackItem helps track the tree building state
 type stackItem struct {
-	node  *Node
-	depth int
+	node    *Node
+	depth   int
+	include bool // The item stands for an @include line
+}
+
+// spliceInclude parses the layout named by an @include line and adds its
+// nodes at the line's depth
+func (p *PlainTextParser) spliceInclude(line parsedLine, depth int, roots *[]*Node, stack *[]*stackItem) error {
+	var included []*Node
+	if p.KeepIncludes {
+		included = []*Node{{Include: line.include, Line: line.lineNum}}
+	} else {
+		var err error
+		if included, err = p.parseInclude(line); err != nil {
+			return err
+		}
+	}
+
+	// Comments above the directive stay with the first included node
+	if line.comment != "" && len(included) > 0 {
+		included[0].Comment = line.comment + included[0].Comment
+	}
+
+	if depth == 0 {
+		*roots = append(*roots, included...)
+	} else {
+		parent := (*stack)[len(*stack)-1].node
+		if (*stack)[len(*stack)-1].include || !parent.IsDir {
+			return NewParseError(line.lineNum,
+				fmt.Sprintf("cannot include '%s' under file '%s' (only directories can have children)",
+					line.include, parent.Name))
+		}
+		for _, node := range included {
+			setPaths(node, parent.Path)
+		}
+		parent.Children = append(parent.Children, included...)
+	}
+
+	// Lines indented below the directive are rejected
+	*stack = append((*stack)[:depth], &stackItem{
+		node:    &Node{Name: "@include " + line.include},
+		depth:   depth,
+		include: true,
+	})
+	return nil
+}
+
+// parseInclude reads the layout named by an @include line, relative to the
+// including file. Files already being included are a cycle.
+func (p *PlainTextParser) parseInclude(line parsedLine) ([]*Node, error) {
+	if p.File == "" {
+		return nil, NewParseError(line.lineNum, "@include needs a layout file on disk to resolve against (not supported for stdin or URLs)")
+	}
+
+	path := line.include
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(p.File), path)
+	}
+
+	chain := append(p.includeChain[:len(p.includeChain):len(p.includeChain)], p.File)
+	for _, seen := range chain {
+		if sameFilePath(seen, path) {
+			return nil, NewParseError(line.lineNum,
+				fmt.Sprintf("include cycle: %s -> %s", strings.Join(chain, " -> "), path))
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, NewParseError(line.lineNum, fmt.Sprintf("cannot @include %s: %v", line.include, err))
+	}
+	defer file.Close()
+
+	// The included layout may use its own indent width
+	child := &PlainTextParser{
+		AutoDetect:   true,
+		TabWidth:     p.TabWidth,
+		Strict:       p.Strict,
+		File:         path,
+		includeChain: chain,
+	}
+	nodes, err := child.Parse(file)
+	if err != nil {
+		return nil, NewParseError(line.lineNum, fmt.Sprintf("in @include %s: %v", line.include, err))
+	}
+	return nodes, nil
+}
+
+// sameFilePath reports whether two paths name the same file
+func sameFilePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// setPaths sets the Path of node and its descendants below parentPath
+func setPaths(node *Node, parentPath string) {
+	node.Path = parentPath + "/" + node.Name
+	for _, child := range node.Children {
+		setPaths(child, node.Path)
+	}
 }
 
 // ValidateIndentation checks if all lines use consistent indentation
@@ -0,0 +1,88 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectIndentation(t *testing.T) {
+	tests := []struct {
+		name    string
+		indents []int
+		want    int
+	}{
+		{name: "no indented lines", indents: []int{0, 0}, want: 2},
+		{name: "two spaces", indents: []int{0, 2, 4, 2}, want: 2},
+		{name: "four spaces", indents: []int{0, 4, 8, 4}, want: 4},
+		{name: "first step of eight", indents: []int{0, 8, 0, 4, 8}, want: 4},
+		{name: "eight then four", indents: []int{0, 4, 12, 8, 12}, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]parsedLine, len(tt.indents))
+			for i, indent := range tt.indents {
+				lines[i].indent = indent
+			}
+			p := NewPlainTextParser(0)
+			p.detectIndentation(lines)
+			if p.IndentWidth != tt.want {
+				t.Errorf("IndentWidth = %d, want %d", p.IndentWidth, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDetectsFourSpaceIndent(t *testing.T) {
+	input := "project/\n" +
+		"    src/\n" +
+		"        main.go\n" +
+		"        lib/\n" +
+		"            util.go\n" +
+		"    README.md\n"
+
+	nodes, err := ParseWithOptions(strings.NewReader(input), FormatPlainText, Options{IndentWidth: 0})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []string{"project/", "project/src/", "project/src/main.go", "project/src/lib/", "project/src/lib/util.go", "project/README.md"}
+	if got := listPaths(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+}
+
+func TestParseCRLF(t *testing.T) {
+	input := "project/\r\n" +
+		"  src/\r\n" +
+		"    main.go\r\n" +
+		"\r\n" +
+		"  # Docs\r\n" +
+		"  README.md\r\n" +
+		"  run.sh*"
+
+	nodes, err := ParseWithOptions(strings.NewReader(input), FormatPlainText, Options{IndentWidth: 2})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []string{"project/", "project/src/", "project/src/main.go", "project/README.md", "project/run.sh"}
+	got := listPaths(nodes)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %q, want %q", got, want)
+	}
+	for _, path := range got {
+		if strings.Contains(path, "\r") {
+			t.Errorf("%q keeps a carriage return", path)
+		}
+	}
+
+	readme := nodes[0].Children[1]
+	if readme.Comment != "\n# Docs\n" {
+		t.Errorf("README.md comment = %q, want %q", readme.Comment, "\n# Docs\n")
+	}
+	if run := nodes[0].Children[2]; !run.Executable {
+		t.Error("run.sh* is not executable")
+	}
+}
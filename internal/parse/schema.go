@@ -0,0 +1,163 @@
+package parse
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// LayoutSchema is the JSON Schema for JSON layout files
+//
+//go:embed layout.schema.json
+var LayoutSchema []byte
+
+// SchemaError is a JSON layout that doesn't match LayoutSchema, located by
+// a JSON pointer such as "/src/main.go"
+type SchemaError struct {
+	Pointer string // JSON pointer to the offending value ("" for the root)
+	Message string
+}
+
+// Error implements the error interface
+func (e *SchemaError) Error() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s", pointer, e.Message)
+}
+
+// layoutSchema is LayoutSchema decoded, for validation
+var layoutSchema = mustDecodeSchema(LayoutSchema)
+
+// mustDecodeSchema decodes the embedded schema, which is known to be valid
+func mustDecodeSchema(data []byte) map[string]interface{} {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		panic(fmt.Sprintf("invalid embedded layout schema: %v", err))
+	}
+	return schema
+}
+
+// validateSchema checks a decoded JSON document against LayoutSchema,
+// returning the first mismatch in document order. Only the keywords the
+// schema uses are supported: type, additionalProperties, items and local
+// $ref.
+func validateSchema(value interface{}) error {
+	return validateValue(layoutSchema, value, "")
+}
+
+// validateValue checks value, found at pointer, against a schema
+func validateValue(schema map[string]interface{}, value interface{}, pointer string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := resolveRef(ref)
+		if err != nil {
+			return err
+		}
+		return validateValue(resolved, value, pointer)
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !hasType(types, jsonType(value)) {
+		return &SchemaError{
+			Pointer: pointer,
+			Message: fmt.Sprintf("expected %s, got %s", joinTypes(types), jsonType(value)),
+		}
+	}
+
+	switch v := value.(type) {
+	case jsonObject:
+		if sub, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			for _, field := range v {
+				if err := validateValue(sub, field.Value, pointer+"/"+escapePointer(field.Key)); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if sub, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateValue(sub, item, fmt.Sprintf("%s/%d", pointer, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveRef looks up a "#/$defs/name" reference in the layout schema
+func resolveRef(ref string) (map[string]interface{}, error) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported schema reference: %s", ref)
+	}
+	defs, _ := layoutSchema["$defs"].(map[string]interface{})
+	def, ok := defs[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unknown schema reference: %s", ref)
+	}
+	return def, nil
+}
+
+// schemaTypes returns the names in a type keyword, which may be a string or
+// a list of strings
+func schemaTypes(keyword interface{}) []string {
+	switch v := keyword.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		types := make([]string, 0, len(v))
+		for _, t := range v {
+			if name, ok := t.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// hasType reports whether name is among types
+func hasType(types []string, name string) bool {
+	for _, t := range types {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// joinTypes lists type names for an error message: "a, b or c"
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return strings.Join(types[:len(types)-1], ", ") + " or " + types[len(types)-1]
+}
+
+// jsonType names the JSON Schema type of a value from decodeJSON
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case jsonObject:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,47 @@
+package parse
+
+// Select returns a copy of the layout keeping only the nodes that match
+// reports true for, along with the directories needed to reach them. A
+// matched directory keeps only its matching descendants. Returns nil when
+// nothing matches; the input is not modified.
+func Select(nodes []*Node, match func(*Node) bool) []*Node {
+	matched := make(map[*Node]bool)
+	for _, node := range nodes {
+		node.Walk(func(n *Node) error {
+			if match(n) {
+				matched[n] = true
+			}
+			return nil
+		})
+	}
+
+	return pruneNodes(nodes, func(n *Node, children []*Node) bool {
+		return matched[n] || len(children) > 0
+	})
+}
+
+// Exclude returns a copy of the layout without the nodes that match reports
+// true for, removing a matched directory with everything below it. The
+// input is not modified.
+func Exclude(nodes []*Node, match func(*Node) bool) []*Node {
+	return pruneNodes(nodes, func(n *Node, children []*Node) bool {
+		return !match(n)
+	})
+}
+
+// pruneNodes copies the nodes that keep accepts, given each node's pruned
+// children
+func pruneNodes(nodes []*Node, keep func(n *Node, children []*Node) bool) []*Node {
+	var kept []*Node
+	for _, node := range nodes {
+		children := pruneNodes(node.Children, keep)
+		if !keep(node, children) {
+			continue
+		}
+
+		pruned := *node
+		pruned.Children = children
+		kept = append(kept, &pruned)
+	}
+	return kept
+}
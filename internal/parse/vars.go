@@ -0,0 +1,109 @@
+package parse
+
+import (
+	"os"
+	"regexp"
+)
+
+// varPattern matches {{name}} placeholders, allowing spaces inside the braces
+var varPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// FindVars returns the distinct placeholder names used in node names and
+// file contents, in order of first appearance
+func FindVars(nodes []*Node) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	collect := func(s string) {
+		for _, match := range varPattern.FindAllStringSubmatch(s, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		node.Walk(func(n *Node) error {
+			collect(n.Name)
+			if n.HasContent && !n.Binary {
+				collect(n.Content)
+			}
+			return nil
+		})
+	}
+
+	return names
+}
+
+// MissingVars returns the placeholder names used in nodes that have no
+// value in vars
+func MissingVars(nodes []*Node, vars map[string]string) []string {
+	var missing []string
+	for _, name := range FindVars(nodes) {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// SubstituteVars replaces placeholders in node names and file contents with
+// their values from vars. Placeholders without a value are left as-is.
+// Node paths are updated to match the new names.
+func SubstituteVars(nodes []*Node, vars map[string]string) {
+	for _, node := range nodes {
+		substituteNode(node, "", vars)
+	}
+}
+
+// substituteNode substitutes within a node and its descendants
+func substituteNode(node *Node, parentPath string, vars map[string]string) {
+	node.Name = expandVars(node.Name, vars)
+	if node.HasContent && !node.Binary {
+		node.Content = expandVars(node.Content, vars)
+	}
+
+	node.Path = node.Name
+	if parentPath != "" {
+		node.Path = parentPath + "/" + node.Name
+	}
+
+	for _, child := range node.Children {
+		substituteNode(child, node.Path, vars)
+	}
+}
+
+// ExpandEnv replaces $VAR and ${VAR} references in node names with the
+// values of environment variables, as os.ExpandEnv does; unset variables
+// become empty. Node paths are updated to match the new names.
+func ExpandEnv(nodes []*Node) {
+	for _, node := range nodes {
+		expandEnvNode(node, "")
+	}
+}
+
+// expandEnvNode expands a node's name and those of its descendants
+func expandEnvNode(node *Node, parentPath string) {
+	node.Name = os.ExpandEnv(node.Name)
+
+	node.Path = node.Name
+	if parentPath != "" {
+		node.Path = parentPath + "/" + node.Name
+	}
+
+	for _, child := range node.Children {
+		expandEnvNode(child, node.Path)
+	}
+}
+
+// expandVars replaces the known placeholders in s
+func expandVars(s string, vars map[string]string) string {
+	return varPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := varPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
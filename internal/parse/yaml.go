@@ -3,7 +3,7 @@ package parse
 import (
 	"fmt"
 	"io"
-	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -24,46 +24,66 @@ func (p *YAMLParser) Parse(reader io.Reader) ([]*Node, error) {
 		return nil, fmt.Errorf("reading input: %w", err)
 	}
 
-	// Parse YAML into a generic structure
-	var content interface{}
-	if err := yaml.Unmarshal(data, &content); err != nil {
+	// Parse YAML into its node AST so that key order is preserved
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("parsing YAML: %w", err)
 	}
 
 	// Handle empty YAML
-	if content == nil {
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return []*Node{}, nil
+	}
+
+	root := resolveAlias(doc.Content[0])
+	if isYAMLNull(root) {
 		return []*Node{}, nil
 	}
 
 	// Convert to nodes
-	switch v := content.(type) {
-	case map[string]interface{}:
+	switch root.Kind {
+	case yaml.MappingNode:
 		// Root is a map - each key becomes a root node
-		return p.parseMap(v, "")
-	case []interface{}:
-		// Root is an array - not supported for layout
-		return nil, fmt.Errorf("YAML root must be an object, not an array")
+		return p.parseMap(root, "")
+	case yaml.SequenceNode:
+		// Root is an array - a list of slash-separated paths
+		return p.parsePathList(root)
 	default:
-		return nil, fmt.Errorf("unexpected YAML root type: %T", content)
+		return nil, yamlError(root, "unexpected YAML root type: %s", yamlTypeName(root))
 	}
 }
 
-// parseMap converts a YAML map to nodes
-func (p *YAMLParser) parseMap(m map[string]interface{}, parentPath string) ([]*Node, error) {
+// parsePathList converts a YAML sequence of path strings to nodes
+func (p *YAMLParser) parsePathList(seq *yaml.Node) ([]*Node, error) {
+	paths := make([]string, 0, len(seq.Content))
+	for i, item := range seq.Content {
+		item = resolveAlias(item)
+		if item.Kind != yaml.ScalarNode || isYAMLNull(item) {
+			return nil, yamlError(seq.Content[i], "YAML root sequence must contain path strings, got %s at index %d", yamlTypeName(item), i)
+		}
+		paths = append(paths, item.Value)
+	}
+	return BuildTreeFromPaths(paths)
+}
+
+// parseMap converts a YAML mapping node to nodes
+func (p *YAMLParser) parseMap(m *yaml.Node, parentPath string) ([]*Node, error) {
 	var nodes []*Node
 
-	// Sort keys for consistent ordering
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
+	// Mapping content alternates key and value nodes, in document order
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		keyNode := resolveAlias(m.Content[i])
+		value := resolveAlias(m.Content[i+1])
+
+		if keyNode.Kind != yaml.ScalarNode {
+			return nil, yamlError(m.Content[i], "non-string key in YAML at '%s'", parentPath)
+		}
 
-	for _, name := range keys {
-		value := m[name]
+		name := keyNode.Value
 		node := &Node{
 			Name: name,
 			Path: name,
+			Line: m.Content[i].Line,
 		}
 
 		if parentPath != "" {
@@ -71,51 +91,42 @@ func (p *YAMLParser) parseMap(m map[string]interface{}, parentPath string) ([]*N
 		}
 
 		// Determine if it's a directory or file based on value
-		switch v := value.(type) {
-		case map[string]interface{}:
-			// Map means directory
+		switch {
+		case isYAMLBase64(value):
+			// {base64: ...} is a file with binary content
+			data := resolveAlias(value.Content[1])
+			if err := checkBase64(data.Value); err != nil {
+				return nil, yamlError(value.Content[1], "invalid base64 content for '%s': %v", node.Path, err)
+			}
+			node.Content = data.Value
+			node.HasContent = true
+			node.Binary = true
+
+		case value.Kind == yaml.MappingNode:
+			// Any other map means directory
 			node.IsDir = true
 
 			// Parse children
-			children, err := p.parseMap(v, node.Path)
+			children, err := p.parseMap(value, node.Path)
 			if err != nil {
 				return nil, err
 			}
 			node.Children = children
 
-		case nil:
+		case isYAMLNull(value):
 			// Null means file
 			node.IsDir = false
 
-		case string:
-			// Empty string also means file
-			if v == "" {
-				node.IsDir = false
-			} else {
-				return nil, fmt.Errorf("unexpected string value for '%s': files should have null or empty string value", name)
-			}
-
-		case map[interface{}]interface{}:
-			// Sometimes YAML parses as map[interface{}]interface{}
-			// Convert to map[string]interface{}
-			converted := make(map[string]interface{})
-			for k, val := range v {
-				key, ok := k.(string)
-				if !ok {
-					return nil, fmt.Errorf("non-string key in YAML at '%s'", name)
-				}
-				converted[key] = val
-			}
-
-			node.IsDir = true
-			children, err := p.parseMap(converted, node.Path)
-			if err != nil {
-				return nil, err
+		case value.Kind == yaml.ScalarNode && value.Tag == "!!str":
+			// Empty string also means file, any other string is its content
+			node.IsDir = false
+			if value.Value != "" {
+				node.Content = value.Value
+				node.HasContent = true
 			}
-			node.Children = children
 
 		default:
-			return nil, fmt.Errorf("unexpected value type for '%s': %T (use null for files, {} for empty directories)", name, value)
+			return nil, yamlError(m.Content[i+1], "unexpected value type for '%s': %s (use null or a string for files, {} for empty directories)", name, yamlTypeName(value))
 		}
 
 		nodes = append(nodes, node)
@@ -124,58 +135,49 @@ func (p *YAMLParser) parseMap(m map[string]interface{}, parentPath string) ([]*N
 	return nodes, nil
 }
 
-// parseYAMLNode is a helper to handle both map[string]interface{} and map[interface{}]interface{}
-func (p *YAMLParser) parseYAMLNode(name string, value interface{}, parentPath string) (*Node, error) {
-	node := &Node{
-		Name: name,
-		Path: name,
+// yamlError returns a ParseError located at a YAML node. For aliases, the
+// position is that of the alias rather than the anchored value.
+func yamlError(n *yaml.Node, format string, args ...interface{}) error {
+	return &ParseError{
+		Line:    n.Line,
+		Column:  n.Column,
+		Message: fmt.Sprintf(format, args...),
 	}
+}
 
-	if parentPath != "" {
-		node.Path = parentPath + "/" + name
+// resolveAlias follows YAML aliases to the node they reference
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
 	}
+	return n
+}
 
-	switch v := value.(type) {
-	case map[string]interface{}:
-		node.IsDir = true
-		children, err := p.parseMap(v, node.Path)
-		if err != nil {
-			return nil, err
-		}
-		node.Children = children
-
-	case map[interface{}]interface{}:
-		// Convert to map[string]interface{}
-		converted := make(map[string]interface{})
-		for k, val := range v {
-			key, ok := k.(string)
-			if !ok {
-				return nil, fmt.Errorf("non-string key in YAML at '%s'", name)
-			}
-			converted[key] = val
-		}
-
-		node.IsDir = true
-		children, err := p.parseMap(converted, node.Path)
-		if err != nil {
-			return nil, err
-		}
-		node.Children = children
-
-	case nil:
-		node.IsDir = false
+// isYAMLBase64 reports whether the node is a {base64: "..."} mapping
+func isYAMLBase64(n *yaml.Node) bool {
+	if n.Kind != yaml.MappingNode || len(n.Content) != 2 {
+		return false
+	}
+	key, value := resolveAlias(n.Content[0]), resolveAlias(n.Content[1])
+	return key.Kind == yaml.ScalarNode && key.Value == Base64Key &&
+		value.Kind == yaml.ScalarNode && value.Tag == "!!str"
+}
 
-	case string:
-		// Empty string means file
-		if v == "" {
-			node.IsDir = false
-		} else {
-			return nil, fmt.Errorf("unexpected string value for '%s': files should have null or empty string value", name)
-		}
+// isYAMLNull reports whether the node is an explicit or implicit null
+func isYAMLNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
 
+// yamlTypeName returns a readable type name for a YAML node
+func yamlTypeName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.MappingNode:
+		return "map"
+	case yaml.ScalarNode:
+		return strings.TrimPrefix(n.Tag, "!!")
 	default:
-		return nil, fmt.Errorf("unexpected value type for '%s': %T", name, value)
+		return "unknown"
 	}
-
-	return node, nil
 }
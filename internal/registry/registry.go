@@ -0,0 +1,318 @@
+// Package registry downloads published packages from npm and PyPI so that
+// their layout can be analyzed
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/httpx"
+)
+
+// Source prefixes that name a package instead of a path or URL
+const (
+	NPMPrefix  = "npm:"
+	PyPIPrefix = "pypi:"
+)
+
+// Default registry base URLs
+const (
+	DefaultNPMURL  = "https://registry.npmjs.org"
+	DefaultPyPIURL = "https://pypi.org"
+)
+
+// IsPackageSource reports whether source names a registry package, as
+// npm:<package>[@<version>] or pypi:<package>[@<version>]
+func IsPackageSource(source string) bool {
+	return strings.HasPrefix(source, NPMPrefix) || strings.HasPrefix(source, PyPIPrefix)
+}
+
+// Package identifies a published package
+type Package struct {
+	Registry string // "npm" or "pypi"
+	Name     string // Package name, such as "left-pad" or "@types/node"
+	Version  string // Version, or "" for the latest
+}
+
+// String returns the package as a source, such as "npm:left-pad@1.3.0"
+func (p Package) String() string {
+	s := p.Registry + ":" + p.Name
+	if p.Version != "" {
+		s += "@" + p.Version
+	}
+	return s
+}
+
+// ParsePackage parses an npm:<package>[@<version>] or
+// pypi:<package>[@<version>] source. Scoped npm packages keep their leading
+// "@", as in npm:@types/node@20.1.0.
+func ParsePackage(source string) (Package, error) {
+	var p Package
+	var spec string
+	switch {
+	case strings.HasPrefix(source, NPMPrefix):
+		p.Registry, spec = "npm", strings.TrimPrefix(source, NPMPrefix)
+	case strings.HasPrefix(source, PyPIPrefix):
+		p.Registry, spec = "pypi", strings.TrimPrefix(source, PyPIPrefix)
+	default:
+		return p, fmt.Errorf("invalid package %q: must start with %s or %s", source, NPMPrefix, PyPIPrefix)
+	}
+
+	// The version follows the last "@" that isn't a scope's leading one
+	p.Name = spec
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		p.Name, p.Version = spec[:i], spec[i+1:]
+		if p.Version == "" {
+			return p, fmt.Errorf("invalid package %q: empty version after '@'", source)
+		}
+	}
+
+	if p.Name == "" || strings.Contains(p.Name, "..") || strings.Count(p.Name, "/") > 1 ||
+		(strings.Contains(p.Name, "/") && !(p.Registry == "npm" && strings.HasPrefix(p.Name, "@"))) {
+		return p, fmt.Errorf("invalid package %q: bad package name", source)
+	}
+	return p, nil
+}
+
+// Download is a package archive saved to a temporary file
+type Download struct {
+	Path string // Archive file, with an extension the archive analyzer knows
+	Name string // Name for the root of the analyzed tree
+}
+
+// Remove deletes the downloaded archive
+func (d *Download) Remove() error {
+	return os.Remove(d.Path)
+}
+
+// Fetcher downloads package archives from the registries
+type Fetcher struct {
+	Retry   httpx.RetryPolicy // Retries for transient failures
+	NPMURL  string            // npm registry base URL
+	PyPIURL string            // PyPI base URL
+
+	// Client sends the requests (see httpx.NewClient). It should have no
+	// overall Timeout, so that large archives on slow links can finish;
+	// the request's context bounds a download instead.
+	Client *http.Client
+}
+
+// NewFetcher creates a fetcher for the public registries
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Client:  httpx.NewClient(httpx.ClientOptions{Timeout: httpx.NoTimeout}),
+		Retry:   httpx.DefaultRetryPolicy,
+		NPMURL:  DefaultNPMURL,
+		PyPIURL: DefaultPyPIURL,
+	}
+}
+
+// errNotFound is returned by getJSON for a 404 response
+var errNotFound = errors.New("not found")
+
+// Fetch resolves a package to its archive and downloads it to a temporary
+// file, which the caller must Remove
+func (f *Fetcher) Fetch(ctx context.Context, p Package) (*Download, error) {
+	var archiveURL string
+	var err error
+	switch p.Registry {
+	case "npm":
+		archiveURL, err = f.npmTarball(ctx, p)
+	case "pypi":
+		archiveURL, err = f.pypiArchive(ctx, p)
+	default:
+		err = fmt.Errorf("unknown registry %q", p.Registry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := f.download(ctx, archiveURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", p, err)
+	}
+	return &Download{Path: path, Name: p.rootName()}, nil
+}
+
+// rootName returns a directory name for the package, without the scope
+func (p Package) rootName() string {
+	return p.Name[strings.LastIndex(p.Name, "/")+1:]
+}
+
+// npmTarball returns the tarball URL of an npm package version
+func (f *Fetcher) npmTarball(ctx context.Context, p Package) (string, error) {
+	version := p.Version
+	if version == "" {
+		version = "latest"
+	}
+
+	var manifest struct {
+		Dist struct {
+			Tarball string `json:"tarball"`
+		} `json:"dist"`
+	}
+	err := f.getJSON(ctx, strings.TrimSuffix(f.NPMURL, "/")+"/"+escapeName(p.Name)+"/"+url.PathEscape(version), &manifest)
+	if err == errNotFound {
+		return "", f.notFound(ctx, p, strings.TrimSuffix(f.NPMURL, "/")+"/"+escapeName(p.Name)+"/latest")
+	}
+	if err != nil {
+		return "", fmt.Errorf("npm registry: %w", err)
+	}
+	if manifest.Dist.Tarball == "" {
+		return "", fmt.Errorf("npm registry lists no tarball for %s", p)
+	}
+	return manifest.Dist.Tarball, nil
+}
+
+// pypiArchive returns the URL of a PyPI release's source distribution, or
+// of a wheel when there is none
+func (f *Fetcher) pypiArchive(ctx context.Context, p Package) (string, error) {
+	base := strings.TrimSuffix(f.PyPIURL, "/") + "/pypi/" + url.PathEscape(p.Name)
+	releaseURL := base + "/json"
+	if p.Version != "" {
+		releaseURL = base + "/" + url.PathEscape(p.Version) + "/json"
+	}
+
+	var release struct {
+		URLs []struct {
+			PackageType string `json:"packagetype"`
+			URL         string `json:"url"`
+		} `json:"urls"`
+	}
+	err := f.getJSON(ctx, releaseURL, &release)
+	if err == errNotFound {
+		return "", f.notFound(ctx, p, base+"/json")
+	}
+	if err != nil {
+		return "", fmt.Errorf("PyPI: %w", err)
+	}
+
+	var wheel string
+	for _, file := range release.URLs {
+		switch {
+		case file.PackageType == "sdist" && archiveExtension(file.URL) != "":
+			return file.URL, nil
+		case file.PackageType == "bdist_wheel" && wheel == "":
+			wheel = file.URL
+		}
+	}
+	if wheel != "" {
+		return wheel, nil
+	}
+	return "", fmt.Errorf("PyPI lists no source distribution or wheel for %s", p)
+}
+
+// notFound explains a 404 for p: the package is missing, or only the
+// requested version is. packageURL is a request that succeeds for any
+// existing package.
+func (f *Fetcher) notFound(ctx context.Context, p Package, packageURL string) error {
+	if p.Version != "" {
+		var ignored struct{}
+		if err := f.getJSON(ctx, packageURL, &ignored); err == nil {
+			return fmt.Errorf("%s package %q has no version %q", registryName(p.Registry), p.Name, p.Version)
+		}
+	}
+	return fmt.Errorf("%s package %q not found", registryName(p.Registry), p.Name)
+}
+
+// getJSON fetches a URL and decodes its JSON body into v, returning
+// errNotFound for a 404
+func (f *Fetcher) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	resp, err := f.Retry.Do(f.Client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// download saves the archive at rawURL to a temporary file, named with the
+// archive's extension, and returns its path
+func (f *Fetcher) download(ctx context.Context, rawURL string) (string, error) {
+	resp, err := f.Retry.Do(f.Client, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d fetching %s", resp.StatusCode, rawURL)
+	}
+
+	file, err := os.CreateTemp("", "chassis-package-*"+archiveExtension(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// archiveExtension returns the extension to save an archive URL with:
+// wheels are zip files, and anything else keeps its extension if the
+// archive analyzer reads it, or gets "" when it doesn't
+func archiveExtension(rawURL string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		name = path.Base(u.Path)
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".whl") {
+		return ".zip"
+	}
+	return analyze.ArchiveExtension(name)
+}
+
+// escapeName escapes a package name for an npm registry path, keeping the
+// slash of a scoped name
+func escapeName(name string) string {
+	scope, pkg, scoped := strings.Cut(name, "/")
+	if !scoped {
+		return url.PathEscape(name)
+	}
+	return url.PathEscape(scope) + "/" + url.PathEscape(pkg)
+}
+
+// registryName returns the display name of a registry
+func registryName(registry string) string {
+	if registry == "pypi" {
+		return "PyPI"
+	}
+	return registry
+}
@@ -0,0 +1,93 @@
+// Package templates manages the registry of named layout files used by
+// 'chassis new'
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirEnv is the environment variable that overrides the template directory
+const DirEnv = "CHASSIS_TEMPLATE_DIR"
+
+// Template is a named layout file in the template directory
+type Template struct {
+	Name string // File name without its extension
+	Path string // Full path of the layout file
+}
+
+// Dir returns the template directory: $CHASSIS_TEMPLATE_DIR if set,
+// otherwise chassis/templates under the user's config dir
+// ($XDG_CONFIG_HOME or ~/.config on Linux)
+func Dir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate template directory: %w", err)
+	}
+	return filepath.Join(dir, "chassis", "templates"), nil
+}
+
+// List returns the templates in dir, sorted by name. A missing directory
+// holds no templates.
+func List(dir string) ([]Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read template directory: %w", err)
+	}
+
+	var templates []Template
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		templates = append(templates, Template{
+			Name: strings.TrimSuffix(name, filepath.Ext(name)),
+			Path: filepath.Join(dir, name),
+		})
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates, nil
+}
+
+// Resolve finds the layout file for a template name in dir. The name may be
+// given with or without the file's extension.
+func Resolve(dir, name string) (string, error) {
+	templates, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	for _, t := range templates {
+		if filepath.Base(t.Path) == name {
+			return t.Path, nil
+		}
+		if t.Name == name {
+			matches = append(matches, t.Path)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("template not found: %s (looked in %s)", name, dir)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("template %s is ambiguous: %s (include the extension)", name, strings.Join(matches, ", "))
+	}
+}
@@ -26,6 +26,7 @@ const (
 	ErrorPathTraversal
 	ErrorInvalidCharacters
 	ErrorReservedName
+	ErrorAbsolutePath
 )
 
 func (e *ValidationError) Error() string {
@@ -41,11 +42,25 @@ type ValidationResult struct {
 	Valid  bool
 }
 
+// Options configures validation
+type Options struct {
+	// CaseInsensitive treats paths differing only in case as duplicates.
+	// This is always the case on Windows and macOS, whose default
+	// filesystems are case-insensitive.
+	CaseInsensitive bool
+}
+
 // Validate checks the tree for syntax and semantic errors
 func Validate(nodes []*parse.Node) error {
+	return ValidateWithOptions(nodes, Options{})
+}
+
+// ValidateWithOptions checks the tree like Validate, using the given options
+func ValidateWithOptions(nodes []*parse.Node, options Options) error {
 	v := &validator{
-		paths:  make(map[string]bool),
-		errors: []error{},
+		paths:           make(map[string]string),
+		errors:          []error{},
+		caseInsensitive: options.CaseInsensitive || runtime.GOOS == "windows" || runtime.GOOS == "darwin",
 	}
 
 	for _, node := range nodes {
@@ -62,8 +77,9 @@ func Validate(nodes []*parse.Node) error {
 
 // validator holds validation state
 type validator struct {
-	paths  map[string]bool // Track paths for duplicate detection
-	errors []error
+	paths           map[string]string // Track paths for duplicate detection, by key
+	errors          []error
+	caseInsensitive bool // Compare paths case-insensitively
 }
 
 // validateNode recursively validates a node and its children
@@ -86,6 +102,17 @@ func (v *validator) validateNode(node *parse.Node, parentPath string) {
 		return
 	}
 
+	// Check for absolute paths, including Windows forms on any OS so that
+	// layouts are rejected consistently wherever they are built
+	if isAbsoluteName(node.Name) {
+		v.errors = append(v.errors, &ValidationError{
+			Path:    fullPath,
+			Message: fmt.Sprintf("absolute paths not allowed: '%s'", node.Name),
+			Type:    ErrorAbsolutePath,
+		})
+		return
+	}
+
 	// Check for path traversal attempts
 	// Reject if name is ".." or starts with "../"
 	if node.Name == ".." || strings.HasPrefix(node.Name, "../") {
@@ -129,21 +156,25 @@ func (v *validator) validateNode(node *parse.Node, parentPath string) {
 		return
 	}
 
-	// Check for duplicates (case-insensitive on Windows)
+	// Check for duplicates (case-insensitive on Windows, macOS, or on request)
 	pathKey := fullPath
-	if runtime.GOOS == "windows" {
+	if v.caseInsensitive {
 		pathKey = strings.ToLower(fullPath)
 	}
 
-	if v.paths[pathKey] {
+	if existing, ok := v.paths[pathKey]; ok {
+		message := "duplicate path"
+		if existing != fullPath {
+			message = fmt.Sprintf("duplicate path: '%s' and '%s' differ only in case", existing, fullPath)
+		}
 		v.errors = append(v.errors, &ValidationError{
 			Path:    fullPath,
-			Message: "duplicate path",
+			Message: message,
 			Type:    ErrorDuplicatePath,
 		})
 		return
 	}
-	v.paths[pathKey] = true
+	v.paths[pathKey] = fullPath
 
 	// Validate children
 	for _, child := range node.Children {
@@ -151,6 +182,20 @@ func (v *validator) validateNode(node *parse.Node, parentPath string) {
 	}
 }
 
+// isAbsoluteName reports whether a node name is an absolute path: absolute
+// for the current OS, rooted with a slash or backslash, or starting with a
+// Windows drive letter such as "C:"
+func isAbsoluteName(name string) bool {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "\\") {
+		return true
+	}
+	if len(name) >= 2 && name[1] == ':' {
+		c := name[0]
+		return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	return false
+}
+
 // validatePathCharacters checks for invalid characters in path
 func (v *validator) validatePathCharacters(name string) error {
 	// Check for null bytes
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pyzamo/chassis/internal/parse"
+)
+
+func TestIsAbsoluteName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"/etc/passwd", true},
+		{`C:\Windows`, true},
+		{"c:/Users", true},
+		{"D:", true},
+		{`\\server\share`, true},
+		{`\Windows`, true},
+		{"src", false},
+		{"main.go", false},
+		{"C", false},
+		{"1:2", false},
+		{"notes:txt", false},
+	}
+
+	for _, tt := range tests {
+		if got := isAbsoluteName(tt.name); got != tt.want {
+			t.Errorf("isAbsoluteName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRejectsWindowsAbsolutePaths(t *testing.T) {
+	// Checked on every OS, so a layout is rejected wherever it is built
+	for _, name := range []string{`C:\Windows`, `\\server\share`, "/etc"} {
+		t.Run(name, func(t *testing.T) {
+			nodes := []*parse.Node{{
+				Name:  "project",
+				IsDir: true,
+				Children: []*parse.Node{
+					{Name: name, IsDir: true, Children: []*parse.Node{{Name: "file.txt"}}},
+				},
+			}}
+
+			err := Validate(nodes)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() = %v, want a *ValidationError", err)
+			}
+			if validationErr.Type != ErrorAbsolutePath {
+				t.Errorf("error type = %v, want ErrorAbsolutePath (%v)", validationErr.Type, err)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsRelativeNames(t *testing.T) {
+	nodes := []*parse.Node{{
+		Name:     "project",
+		IsDir:    true,
+		Children: []*parse.Node{{Name: "src", IsDir: true}, {Name: "README.md"}},
+	}}
+	if err := Validate(nodes); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
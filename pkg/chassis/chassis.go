@@ -0,0 +1,167 @@
+// Package chassis is the public library API for chassis. It lets other Go
+// programs parse layout files, manipulate the resulting node trees, export
+// them, and generate filesystem structures without importing internal
+// packages. The types are aliases of the internal implementation.
+package chassis
+
+import (
+	"io"
+
+	"github.com/pyzamo/chassis/internal/analyze"
+	"github.com/pyzamo/chassis/internal/generate"
+	"github.com/pyzamo/chassis/internal/parse"
+	"github.com/pyzamo/chassis/internal/validate"
+)
+
+// Node represents a single entry in the directory tree
+type Node = parse.Node
+
+// Format represents the supported layout file formats
+type Format = parse.Format
+
+// Supported layout formats
+const (
+	FormatUnknown   = parse.FormatUnknown
+	FormatPlainText = parse.FormatPlainText
+	FormatYAML      = parse.FormatYAML
+	FormatJSON      = parse.FormatJSON
+	FormatPaths     = parse.FormatPaths
+)
+
+// Exporter exports node trees to the layout formats
+type Exporter = analyze.Exporter
+
+// Generator creates filesystem structures from node trees
+type Generator = generate.Generator
+
+// Options configures a Generator
+type Options = generate.Options
+
+// Result contains statistics about a generation run
+type Result = generate.Result
+
+// GenerateError describes a failed filesystem operation on one path
+type GenerateError = generate.GenerateError
+
+// Logger receives generation progress messages
+type Logger = generate.Logger
+
+// EventLogger is a Logger that also takes each generation action as a
+// typed Event
+type EventLogger = generate.EventLogger
+
+// Event describes one action taken on a path during generation
+type Event = generate.Event
+
+// ConsoleLogger is a Logger that writes to the console
+type ConsoleLogger = generate.ConsoleLogger
+
+// JSONLogger is an EventLogger that writes one JSON object per event
+type JSONLogger = generate.JSONLogger
+
+// DetectFormat determines the format based on file extension
+func DetectFormat(filename string) Format {
+	return parse.DetectFormat(filename)
+}
+
+// DetectFormatFromContent determines the format by peeking at the content,
+// returning a reader that still yields the full content
+func DetectFormatFromContent(reader io.Reader) (Format, io.Reader) {
+	return parse.DetectFormatFromContent(reader)
+}
+
+// Parse reads a layout in the given format and returns its root nodes
+func Parse(reader io.Reader, format Format) ([]*Node, error) {
+	return parse.Parse(reader, format)
+}
+
+// ParseWithIndent parses like Parse, using a specific indent width for plain-text
+func ParseWithIndent(reader io.Reader, format Format, indentWidth int) ([]*Node, error) {
+	return parse.ParseWithIndent(reader, format, indentWidth)
+}
+
+// ParseOptions configures ParseWithOptions
+type ParseOptions = parse.Options
+
+// ParseWithOptions parses like Parse, using the given parser options
+func ParseWithOptions(reader io.Reader, format Format, options ParseOptions) ([]*Node, error) {
+	return parse.ParseWithOptions(reader, format, options)
+}
+
+// FindVars returns the distinct {{name}} placeholders used in the tree
+func FindVars(nodes []*Node) []string {
+	return parse.FindVars(nodes)
+}
+
+// SubstituteVars replaces {{name}} placeholders with values from vars
+func SubstituteVars(nodes []*Node, vars map[string]string) {
+	parse.SubstituteVars(nodes, vars)
+}
+
+// MergeNodes merges two layouts, combining same-named directories
+func MergeNodes(a, b []*Node) ([]*Node, error) {
+	return parse.MergeNodes(a, b)
+}
+
+// ExpandEnv replaces $VAR and ${VAR} in node names with environment values
+func ExpandEnv(nodes []*Node) {
+	parse.ExpandEnv(nodes)
+}
+
+// Select returns a copy of the layout with only the nodes match reports
+// true for, plus the directories leading to them
+func Select(nodes []*Node, match func(*Node) bool) []*Node {
+	return parse.Select(nodes, match)
+}
+
+// Exclude returns a copy of the layout without the nodes match reports true
+// for, or anything below them
+func Exclude(nodes []*Node, match func(*Node) bool) []*Node {
+	return parse.Exclude(nodes, match)
+}
+
+// DiffNodes describes how two layouts differ in shape, one line per
+// difference (nil when they match); see Node.Equal
+func DiffNodes(a, b []*Node) []string {
+	return parse.DiffNodes(a, b)
+}
+
+// Validate checks the tree for errors such as duplicates and invalid names
+func Validate(nodes []*Node) error {
+	return validate.Validate(nodes)
+}
+
+// NewExporter creates an exporter for the given nodes
+func NewExporter(nodes []*Node) *Exporter {
+	return analyze.NewExporter(nodes)
+}
+
+// NewGenerator creates a generator with the given options and logger
+func NewGenerator(options Options, logger Logger) *Generator {
+	return generate.NewGenerator(options, logger)
+}
+
+// Generate creates the filesystem structure for nodes under targetDir
+func Generate(nodes []*Node, targetDir string, verbose bool) (*Result, error) {
+	return generate.Generate(nodes, targetDir, &generate.ConsoleLogger{VerboseMode: verbose})
+}
+
+// GenerateWithLogger creates the filesystem structure like Generate,
+// reporting progress to logger instead of the console
+func GenerateWithLogger(nodes []*Node, targetDir string, logger Logger) (*Result, error) {
+	return generate.Generate(nodes, targetDir, logger)
+}
+
+// FormatOptions controls the canonical form produced by Canonical
+type FormatOptions = analyze.FormatOptions
+
+// Canonical returns the canonical plain-text form of a layout
+func Canonical(nodes []*Node, options FormatOptions) (string, error) {
+	return analyze.Canonical(nodes, options)
+}
+
+// RoundTrip parses a layout, re-exports it with Canonical, and reports
+// whether the input was already canonical
+func RoundTrip(data []byte, format Format, options FormatOptions) (string, bool, error) {
+	return analyze.RoundTrip(data, format, options)
+}